@@ -0,0 +1,172 @@
+package cloudcompute
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/batch"
+	"github.com/aws/aws-sdk-go-v2/service/batch/types"
+)
+
+// Compares the latest registered job definition with a requested job definition.
+// Each field is reduced to a canonical string so that ordering and the defaults
+// AWS fills in on registration do not register as drift.
+func jobDefinitionDrift(registered *types.JobDefinition, requested *batch.RegisterJobDefinitionInput) []PluginChange {
+	rcp := registered.ContainerProperties
+	if rcp == nil {
+		rcp = &types.ContainerProperties{}
+	}
+	qcp := requested.ContainerProperties
+
+	fields := []struct {
+		name     string
+		previous string
+		current  string
+	}{
+		{"image", aws.ToString(rcp.Image), aws.ToString(qcp.Image)},
+		{"command", describeCommand(rcp.Command), describeCommand(qcp.Command)},
+		{"environment", describeKvps(rcp.Environment), describeKvps(qcp.Environment)},
+		{"resources", describeResources(rcp.ResourceRequirements), describeResources(qcp.ResourceRequirements)},
+		{"linux_parameters", describeLinuxParameters(rcp.LinuxParameters), describeLinuxParameters(qcp.LinuxParameters)},
+		{"ulimits", describeUlimits(rcp.Ulimits), describeUlimits(qcp.Ulimits)},
+		{"ephemeral_storage", describeEphemeralStorage(rcp.EphemeralStorage), describeEphemeralStorage(qcp.EphemeralStorage)},
+		{"secrets", describeSecrets(rcp.Secrets), describeSecrets(qcp.Secrets)},
+		{"volumes", describeVolumes(rcp.Volumes, rcp.MountPoints), describeVolumes(qcp.Volumes, qcp.MountPoints)},
+		{"timeout", describeTimeout(registered.Timeout), describeTimeout(requested.Timeout)},
+		{"platform", describePlatform(registered.PlatformCapabilities, rcp), describePlatform(requested.PlatformCapabilities, qcp)},
+	}
+
+	changes := []PluginChange{}
+	for _, f := range fields {
+		if f.previous != f.current {
+			changes = append(changes, PluginChange{
+				Field:    f.name,
+				Previous: f.previous,
+				Current:  f.current,
+			})
+		}
+	}
+	return changes
+}
+
+func describeCommand(command []string) string {
+	if len(command) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%q", command)
+}
+
+func describeKvps(kvps []types.KeyValuePair) string {
+	vals := make([]string, len(kvps))
+	for i, kvp := range kvps {
+		vals[i] = fmt.Sprintf("%s=%s", aws.ToString(kvp.Name), aws.ToString(kvp.Value))
+	}
+	return sortAndJoin(vals)
+}
+
+func describeResources(rrs []types.ResourceRequirement) string {
+	vals := make([]string, len(rrs))
+	for i, rr := range rrs {
+		vals[i] = fmt.Sprintf("%s=%s", rr.Type, aws.ToString(rr.Value))
+	}
+	return sortAndJoin(vals)
+}
+
+func describeLinuxParameters(lp *types.LinuxParameters) string {
+	if lp == nil {
+		return ""
+	}
+	vals := []string{}
+	if aws.ToInt32(lp.SharedMemorySize) > 0 {
+		vals = append(vals, fmt.Sprintf("shared_memory=%d", aws.ToInt32(lp.SharedMemorySize)))
+	}
+	for _, d := range lp.Devices {
+		perms := make([]string, len(d.Permissions))
+		for i, p := range d.Permissions {
+			perms[i] = string(p)
+		}
+		sort.Strings(perms)
+		vals = append(vals, fmt.Sprintf("device=%s:%s:%s", aws.ToString(d.HostPath), aws.ToString(d.ContainerPath), strings.Join(perms, "|")))
+	}
+	return sortAndJoin(vals)
+}
+
+func describeUlimits(ulimits []types.Ulimit) string {
+	vals := make([]string, len(ulimits))
+	for i, ul := range ulimits {
+		vals[i] = fmt.Sprintf("%s=%d:%d", aws.ToString(ul.Name), aws.ToInt32(ul.SoftLimit), aws.ToInt32(ul.HardLimit))
+	}
+	return sortAndJoin(vals)
+}
+
+func describeEphemeralStorage(es *types.EphemeralStorage) string {
+	if es == nil || aws.ToInt32(es.SizeInGiB) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d GiB", aws.ToInt32(es.SizeInGiB))
+}
+
+func describeSecrets(secrets []types.Secret) string {
+	vals := make([]string, len(secrets))
+	for i, s := range secrets {
+		vals[i] = fmt.Sprintf("%s=%s", aws.ToString(s.Name), aws.ToString(s.ValueFrom))
+	}
+	return sortAndJoin(vals)
+}
+
+func describeVolumes(volumes []types.Volume, mountPoints []types.MountPoint) string {
+	vals := []string{}
+	for _, v := range volumes {
+		switch {
+		case v.EfsVolumeConfiguration != nil:
+			efs := v.EfsVolumeConfiguration
+			accessPoint := ""
+			if efs.AuthorizationConfig != nil {
+				accessPoint = aws.ToString(efs.AuthorizationConfig.AccessPointId)
+			}
+			vals = append(vals, fmt.Sprintf("volume=%s:efs:%s:%s:%s", aws.ToString(v.Name), aws.ToString(efs.FileSystemId), accessPoint, aws.ToString(efs.RootDirectory)))
+		case v.Host != nil:
+			vals = append(vals, fmt.Sprintf("volume=%s:host:%s", aws.ToString(v.Name), aws.ToString(v.Host.SourcePath)))
+		default:
+			vals = append(vals, fmt.Sprintf("volume=%s", aws.ToString(v.Name)))
+		}
+	}
+	for _, mp := range mountPoints {
+		vals = append(vals, fmt.Sprintf("mount=%s:%s:%t", aws.ToString(mp.SourceVolume), aws.ToString(mp.ContainerPath), aws.ToBool(mp.ReadOnly)))
+	}
+	return sortAndJoin(vals)
+}
+
+func describeTimeout(timeout *types.JobTimeout) string {
+	if timeout == nil || timeout.AttemptDurationSeconds == nil {
+		return ""
+	}
+	return fmt.Sprintf("%ds", *timeout.AttemptDurationSeconds)
+}
+
+// EC2 is the AWS default when no platform capability is given
+func describePlatform(capabilities []types.PlatformCapability, cp *types.ContainerProperties) string {
+	platform := string(types.PlatformCapabilityEc2)
+	if len(capabilities) > 0 {
+		platform = string(capabilities[0])
+	}
+	if platform != string(types.PlatformCapabilityFargate) {
+		return platform
+	}
+	assignPublicIp := types.AssignPublicIpDisabled
+	if cp.NetworkConfiguration != nil && cp.NetworkConfiguration.AssignPublicIp != "" {
+		assignPublicIp = cp.NetworkConfiguration.AssignPublicIp
+	}
+	version := "LATEST"
+	if cp.FargatePlatformConfiguration != nil && cp.FargatePlatformConfiguration.PlatformVersion != nil {
+		version = *cp.FargatePlatformConfiguration.PlatformVersion
+	}
+	return fmt.Sprintf("%s public_ip=%s version=%s", platform, assignPublicIp, version)
+}
+
+func sortAndJoin(vals []string) string {
+	sort.Strings(vals)
+	return strings.Join(vals, ", ")
+}
@@ -0,0 +1,60 @@
+package cloudcompute
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/batch/types"
+)
+
+func TestJobDefinitionDrift(t *testing.T) {
+	abp := AwsBatchProvider{executionRole: "arn:aws:iam::123456789012:role/batch"}
+	timeout := int32(3600)
+	plugin := Plugin{
+		Name:               "mmc-timing",
+		ImageAndTag:        "mmc-timing:1.0",
+		Command:            []string{"/app/mmc-timing", "Ref::param1"},
+		ComputeEnvironment: PluginComputeEnvironment{VCPU: "1", Memory: "2048"},
+		DefaultEnvironment: []KeyValuePair{{"A", "1"}, {"B", "2"}},
+		Credentials:        []KeyValuePair{{"AWS_ACCESS_KEY_ID", "arn:aws:secretsmanager:us-east-1:123456789012:secret:s3:AWS_ACCESS_KEY_ID::"}},
+		ExecutionTimeout:   &timeout,
+	}
+	requested, err := abp.pluginToJobDefinition(&plugin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//AWS returns the registered values but does not preserve the environment order
+	cp := *requested.ContainerProperties
+	cp.Image = aws.String("mmc-timing:1.0")
+	cp.Environment = []types.KeyValuePair{
+		{Name: aws.String("B"), Value: aws.String("2")},
+		{Name: aws.String("A"), Value: aws.String("1")},
+	}
+	registered := types.JobDefinition{
+		JobDefinitionName:   aws.String(plugin.Name),
+		Revision:            aws.Int32(3),
+		ContainerProperties: &cp,
+		Timeout:             requested.Timeout,
+	}
+	if changes := jobDefinitionDrift(&registered, requested); len(changes) != 0 {
+		t.Errorf("expected no drift, got %v", changes)
+	}
+
+	plugin.ImageAndTag = "mmc-timing:1.1"
+	plugin.ExecutionTimeout = nil
+	requested, err = abp.pluginToJobDefinition(&plugin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := jobDefinitionDrift(&registered, requested)
+	if len(changes) != 2 {
+		t.Fatalf("expected image and timeout drift, got %v", changes)
+	}
+	if changes[0].Field != "image" || changes[0].Previous != "mmc-timing:1.0" || changes[0].Current != "mmc-timing:1.1" {
+		t.Errorf("unexpected image change: %v", changes[0])
+	}
+	if changes[1].Field != "timeout" || changes[1].Previous != "3600s" || changes[1].Current != "" {
+		t.Errorf("unexpected timeout change: %v", changes[1])
+	}
+}
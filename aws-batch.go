@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -17,8 +19,14 @@ import (
 )
 
 var awsLogGroup string = "/aws/batch/job"
+
+// job definition tag recording when a plugin revision was registered
+var pluginRegisteredTag string = "cc-registered"
 var ctx context.Context = context.Background()
 
+// maximum number of jobs in a DescribeJobs request
+const maxDescribeJobs int = 100
+
 //options are any set of valid AWS Batch config options.
 //for example, to set max retries to unlimited:
 /*
@@ -107,34 +115,50 @@ func (abp *AwsBatchProvider) SubmitJob(job *Job) error {
 }
 
 func (abp *AwsBatchProvider) RegisterPlugin(plugin *Plugin) (PluginRegistrationOutput, error) {
-	var timout *types.JobTimeout
-	if plugin.ExecutionTimeout != nil {
-		timout = &types.JobTimeout{AttemptDurationSeconds: plugin.ExecutionTimeout}
+	input, err := abp.pluginToJobDefinition(plugin)
+	if err != nil {
+		return PluginRegistrationOutput{}, err
 	}
-	input := &batch.RegisterJobDefinitionInput{
-		JobDefinitionName: &plugin.Name,
-		Type:              types.JobDefinitionTypeContainer,
-		ContainerProperties: &types.ContainerProperties{
-			Command:          plugin.Command,
-			Environment:      kvpToBatchKvp(plugin.DefaultEnvironment),
-			ExecutionRoleArn: &abp.executionRole,
-			Image:            &plugin.ImageAndTag,
-			//MountPoints:      volumesToBatch(plugin.Volumes),
-			//Volumes
-			ResourceRequirements: []types.ResourceRequirement{
-				{
-					Type:  types.ResourceTypeMemory,
-					Value: &plugin.ComputeEnvironment.Memory,
-				},
-				{
-					Type:  types.ResourceTypeVcpu,
-					Value: &plugin.ComputeEnvironment.VCPU,
+	return abp.registerJobDefinition(input)
+}
+
+// Registers a new plugin revision only if the plugin differs from the latest active
+// revision registered in AWS Batch.  The output includes the list of changes that
+// triggered the registration.
+func (abp *AwsBatchProvider) EnsurePlugin(plugin *Plugin) (EnsurePluginOutput, error) {
+	input, err := abp.pluginToJobDefinition(plugin)
+	if err != nil {
+		return EnsurePluginOutput{}, err
+	}
+	latest, err := abp.latestJobDefinition(plugin.Name)
+	if err != nil {
+		return EnsurePluginOutput{}, err
+	}
+	var changes []PluginChange
+	if latest != nil {
+		changes = jobDefinitionDrift(latest, input)
+		if len(changes) == 0 {
+			return EnsurePluginOutput{
+				PluginRegistrationOutput: PluginRegistrationOutput{
+					Name:         aws.ToString(latest.JobDefinitionName),
+					ResourceName: aws.ToString(latest.JobDefinitionArn),
+					Revision:     aws.ToInt32(latest.Revision),
 				},
-			},
-			Secrets: credsToBatchSecrets(plugin.Credentials),
-		},
-		Timeout: timout,
+			}, nil
+		}
 	}
+	pro, err := abp.registerJobDefinition(input)
+	if err != nil {
+		return EnsurePluginOutput{}, err
+	}
+	return EnsurePluginOutput{
+		PluginRegistrationOutput: pro,
+		Registered:               true,
+		Changes:                  changes,
+	}, nil
+}
+
+func (abp *AwsBatchProvider) registerJobDefinition(input *batch.RegisterJobDefinitionInput) (PluginRegistrationOutput, error) {
 	output, err := abp.client.RegisterJobDefinition(ctx, input)
 	pro := PluginRegistrationOutput{}
 	if err == nil {
@@ -147,15 +171,275 @@ func (abp *AwsBatchProvider) RegisterPlugin(plugin *Plugin) (PluginRegistrationO
 	return pro, err
 }
 
+// Validates a plugin and converts it into an AWS Batch job definition
+func (abp *AwsBatchProvider) pluginToJobDefinition(plugin *Plugin) (*batch.RegisterJobDefinitionInput, error) {
+	err := plugin.Validate()
+	if err != nil {
+		return nil, err
+	}
+	var timout *types.JobTimeout
+	if plugin.ExecutionTimeout != nil {
+		timout = &types.JobTimeout{AttemptDurationSeconds: plugin.ExecutionTimeout}
+	}
+	platform := plugin.Platform()
+	if platform.IsFargate() && abp.executionRole == "" {
+		return nil, fmt.Errorf("Invalid plugin %s: Fargate plugins require an execution role", plugin.Name)
+	}
+	mountPoints, volumes := volumesToBatch(plugin.Volumes)
+	networkConfig, fargateConfig := fargateConfigToBatch(plugin)
+	return &batch.RegisterJobDefinitionInput{
+		JobDefinitionName:    &plugin.Name,
+		Type:                 types.JobDefinitionTypeContainer,
+		PlatformCapabilities: []types.PlatformCapability{platformToBatch(platform)},
+		Tags:                 map[string]string{pluginRegisteredTag: time.Now().UTC().Format(time.RFC3339)},
+		ContainerProperties: &types.ContainerProperties{
+			Command:                      plugin.Command,
+			Environment:                  kvpToBatchKvp(plugin.DefaultEnvironment),
+			ExecutionRoleArn:             &abp.executionRole,
+			Image:                        &plugin.ImageAndTag,
+			MountPoints:                  mountPoints,
+			Volumes:                      volumes,
+			ResourceRequirements:         resourcesToBatch(plugin.ComputeEnvironment),
+			LinuxParameters:              linuxParametersToBatch(plugin.ComputeEnvironment),
+			Ulimits:                      ulimitsToBatch(plugin.ComputeEnvironment.Ulimits),
+			EphemeralStorage:             ephemeralStorageToBatch(plugin.ComputeEnvironment),
+			Secrets:                      credsToBatchSecrets(plugin.Credentials),
+			NetworkConfiguration:         networkConfig,
+			FargatePlatformConfiguration: fargateConfig,
+		},
+		Timeout: timout,
+	}, nil
+}
+
+// Retrieves the highest active revision of a job definition.
+// Returns nil if the job definition has never been registered or has no active revisions.
+func (abp *AwsBatchProvider) latestJobDefinition(name string) (*types.JobDefinition, error) {
+	var latest *types.JobDefinition
+	var nextToken *string
+	for {
+		input := batch.DescribeJobDefinitionsInput{
+			JobDefinitionName: &name,
+			Status:            aws.String("ACTIVE"),
+			NextToken:         nextToken,
+		}
+		output, err := abp.client.DescribeJobDefinitions(ctx, &input)
+		if err != nil {
+			return nil, err
+		}
+		for i, jd := range output.JobDefinitions {
+			if latest == nil || aws.ToInt32(jd.Revision) > aws.ToInt32(latest.Revision) {
+				latest = &output.JobDefinitions[i]
+			}
+		}
+		nextToken = output.NextToken
+		if nextToken == nil {
+			break
+		}
+	}
+	return latest, nil
+}
+
+// Retrieves a registered plugin.  If the revision is omitted the latest active revision is returned.
+func (abp *AwsBatchProvider) GetPlugin(nameAndRevision string) (Plugin, error) {
+	if !strings.Contains(nameAndRevision, ":") {
+		jd, err := abp.latestJobDefinition(nameAndRevision)
+		if err != nil {
+			return Plugin{}, err
+		}
+		if jd == nil {
+			return Plugin{}, fmt.Errorf("Plugin %s has no active revisions", nameAndRevision)
+		}
+		return jobDefinitionToPlugin(*jd)
+	}
+	input := batch.DescribeJobDefinitionsInput{
+		JobDefinitions: []string{nameAndRevision},
+	}
+	output, err := abp.client.DescribeJobDefinitions(ctx, &input)
+	if err != nil {
+		return Plugin{}, err
+	}
+	if len(output.JobDefinitions) == 0 {
+		return Plugin{}, fmt.Errorf("Invalid plugin: %s", nameAndRevision)
+	}
+	return jobDefinitionToPlugin(output.JobDefinitions[0])
+}
+
+// Lists registered plugins ordered by name and revision.
+// Job definitions that are not single container definitions are skipped.
+func (abp *AwsBatchProvider) ListPlugins(filter PluginFilter) ([]Plugin, error) {
+	status := filter.Status
+	if status == "" {
+		status = "ACTIVE"
+	}
+	var jobDefinitionName *string
+	if filter.Name != "" {
+		jobDefinitionName = &filter.Name
+	}
+
+	plugins := []Plugin{}
+	var nextToken *string
+	for {
+		input := batch.DescribeJobDefinitionsInput{
+			JobDefinitionName: jobDefinitionName,
+			Status:            &status,
+			NextToken:         nextToken,
+		}
+		output, err := abp.client.DescribeJobDefinitions(ctx, &input)
+		if err != nil {
+			return nil, err
+		}
+		for _, jd := range output.JobDefinitions {
+			if jd.ContainerProperties == nil {
+				continue
+			}
+			plugin, err := jobDefinitionToPlugin(jd)
+			if err != nil {
+				return nil, err
+			}
+			plugins = append(plugins, plugin)
+		}
+		nextToken = output.NextToken
+		if nextToken == nil {
+			break
+		}
+	}
+
+	sort.Slice(plugins, func(i, j int) bool {
+		if plugins[i].Name == plugins[j].Name {
+			return plugins[i].Revision < plugins[j].Revision
+		}
+		return plugins[i].Name < plugins[j].Name
+	})
+
+	if filter.LatestOnly {
+		latest := []Plugin{}
+		for i, p := range plugins {
+			if i == len(plugins)-1 || plugins[i+1].Name != p.Name {
+				latest = append(latest, p)
+			}
+		}
+		plugins = latest
+	}
+	return plugins, nil
+}
+
 func (abp *AwsBatchProvider) UnregisterPlugin(nameAndRevision string) error {
 	dji := batch.DeregisterJobDefinitionInput{
 		JobDefinition: &nameAndRevision,
 	}
 	_, err := abp.client.DeregisterJobDefinition(ctx, &dji)
-	log.Printf("Unable to deregister AWS Batch Job: %s.  Error: %s\n", nameAndRevision, err)
+	if err != nil {
+		log.Printf("Unable to deregister AWS Batch Job: %s.  Error: %s\n", nameAndRevision, err)
+	}
 	return err
 }
 
+// Deregisters old active revisions of a plugin.
+// The latest KeepLatest revisions are always kept, as are revisions referenced by
+// active jobs in the input job queues.  Revisions registered before the registration
+// timestamp tag was introduced have an unknown age and are treated as older than OlderThan.
+func (abp *AwsBatchProvider) PrunePlugins(input PrunePluginsInput) (PrunePluginsOutput, error) {
+	if input.Name == "" {
+		return PrunePluginsOutput{}, errors.New("Missing plugin name to prune")
+	}
+	if input.KeepLatest < 1 {
+		return PrunePluginsOutput{}, errors.New("Pruning must keep at least the latest plugin revision")
+	}
+
+	jds := []types.JobDefinition{}
+	var nextToken *string
+	for {
+		dji := batch.DescribeJobDefinitionsInput{
+			JobDefinitionName: &input.Name,
+			Status:            aws.String("ACTIVE"),
+			NextToken:         nextToken,
+		}
+		output, err := abp.client.DescribeJobDefinitions(ctx, &dji)
+		if err != nil {
+			return PrunePluginsOutput{}, err
+		}
+		jds = append(jds, output.JobDefinitions...)
+		nextToken = output.NextToken
+		if nextToken == nil {
+			break
+		}
+	}
+
+	inUse := make(map[string]bool)
+	for _, queue := range input.JobQueues {
+		err := abp.QueueSummary(queue, JobsSummaryQuery{
+			JobSummaryFunction: func(summaries []JobSummary) {
+				for _, s := range summaries {
+					inUse[s.JobDefinition] = true
+				}
+			},
+		})
+		if err != nil {
+			return PrunePluginsOutput{}, err
+		}
+	}
+
+	var cutoff time.Time
+	if input.OlderThan > 0 {
+		cutoff = time.Now().Add(-input.OlderThan)
+	}
+	prunable, kept := selectPrunableRevisions(jds, input.KeepLatest, cutoff, inUse)
+
+	output := PrunePluginsOutput{
+		DryRun: input.DryRun,
+		InUse:  kept,
+	}
+	var failed []string
+	for _, pro := range prunable {
+		if !input.DryRun {
+			err := abp.UnregisterPlugin(fmt.Sprintf("%s:%d", pro.Name, pro.Revision))
+			if err != nil {
+				failed = append(failed, fmt.Sprint(pro.Revision))
+				continue
+			}
+		}
+		output.Removed = append(output.Removed, pro)
+	}
+	if len(failed) > 0 {
+		return output, fmt.Errorf("Unable to deregister revisions %s of plugin %s", strings.Join(failed, ", "), input.Name)
+	}
+	return output, nil
+}
+
+// Selects the revisions to prune from a set of active job definitions for a single plugin.
+// Returns the revisions to prune and the otherwise prunable revisions kept because they are in use.
+func selectPrunableRevisions(jds []types.JobDefinition, keepLatest int, cutoff time.Time, inUse map[string]bool) ([]PluginRegistrationOutput, []PluginRegistrationOutput) {
+	sorted := make([]types.JobDefinition, len(jds))
+	copy(sorted, jds)
+	sort.Slice(sorted, func(i, j int) bool {
+		return aws.ToInt32(sorted[i].Revision) > aws.ToInt32(sorted[j].Revision)
+	})
+
+	prunable := []PluginRegistrationOutput{}
+	kept := []PluginRegistrationOutput{}
+	for i, jd := range sorted {
+		if i < keepLatest {
+			continue
+		}
+		if !cutoff.IsZero() {
+			if registered, err := time.Parse(time.RFC3339, jd.Tags[pluginRegisteredTag]); err == nil && registered.After(cutoff) {
+				continue
+			}
+		}
+		pro := PluginRegistrationOutput{
+			Name:         aws.ToString(jd.JobDefinitionName),
+			ResourceName: aws.ToString(jd.JobDefinitionArn),
+			Revision:     aws.ToInt32(jd.Revision),
+		}
+		if inUse[pro.ResourceName] {
+			kept = append(kept, pro)
+			continue
+		}
+		prunable = append(prunable, pro)
+	}
+	return prunable, kept
+}
+
 // Terminates jobs submitted to AWS Batch job queues
 func (abp *AwsBatchProvider) TerminateJobs(input TermminateJobInput) error {
 	jobs := input.VendorJobs
@@ -217,6 +501,27 @@ func (abp *AwsBatchProvider) terminateJob(name string, id string, reason string)
 	}
 }
 
+// Status and QueueSummary make each page request through the JobsSummaryQuery PageRequest
+func (abp *AwsBatchProvider) PagesSummaries() bool {
+	return true
+}
+
+// lists a page of jobs and the job summaries for the page through the query PageRequest
+func (abp *AwsBatchProvider) listJobsPage(input *batch.ListJobsInput, query JobsSummaryQuery) (*batch.ListJobsOutput, []JobSummary, error) {
+	var output *batch.ListJobsOutput
+	var summaries []JobSummary
+	err := query.requestPage(func() error {
+		var err error
+		output, err = abp.client.ListJobs(ctx, input)
+		if err != nil {
+			return err
+		}
+		summaries, err = abp.jobSummaries(output, query.IncludeTags)
+		return err
+	})
+	return output, summaries, err
+}
+
 func (abp *AwsBatchProvider) QueueSummary(jobQueue string, query JobsSummaryQuery) error {
 	if query.JobSummaryFunction == nil {
 		return errors.New("Missing JubSummaryFunction.  You have no way to process the result.")
@@ -239,11 +544,11 @@ func (abp *AwsBatchProvider) QueueSummary(jobQueue string, query JobsSummaryQuer
 				NextToken: nextToken,
 			}
 
-			output, err := abp.client.ListJobs(ctx, &input)
+			output, summaries, err := abp.listJobsPage(&input, query)
 			if err != nil {
 				return err
 			}
-			query.JobSummaryFunction(listOutput2JobSummary(output))
+			query.JobSummaryFunction(summaries)
 			nextToken = output.NextToken
 			if nextToken == nil {
 				break
@@ -282,11 +587,11 @@ func (abp *AwsBatchProvider) Status(jobQueue string, query JobsSummaryQuery) err
 			NextToken: nextToken,
 		}
 
-		output, err := abp.client.ListJobs(ctx, &input)
+		output, summaries, err := abp.listJobsPage(&input, query)
 		if err != nil {
 			return err
 		}
-		query.JobSummaryFunction(listOutput2JobSummary(output))
+		query.JobSummaryFunction(summaries)
 		nextToken = output.NextToken
 		if nextToken == nil {
 			break
@@ -376,18 +681,50 @@ func toBatchDependency(jobDependency []JobDependency) []types.JobDependency {
 	return batchDeps
 }
 
+// Converts a page of listed jobs to job summaries.  ListJobs does not return job tags
+// so the tags are read with DescribeJobs when they are requested.
+func (abp *AwsBatchProvider) jobSummaries(output *batch.ListJobsOutput, includeTags bool) ([]JobSummary, error) {
+	summaries := listOutput2JobSummary(output)
+	if !includeTags {
+		return summaries, nil
+	}
+	tags := make(map[string]map[string]string, len(summaries))
+	for start := 0; start < len(summaries); start += maxDescribeJobs {
+		end := start + maxDescribeJobs
+		if end > len(summaries) {
+			end = len(summaries)
+		}
+		ids := make([]string, end-start)
+		for i, s := range summaries[start:end] {
+			ids[i] = s.JobId
+		}
+		desc, err := abp.describeBatchJobs(ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, job := range desc.Jobs {
+			tags[aws.ToString(job.JobId)] = job.Tags
+		}
+	}
+	for i := range summaries {
+		summaries[i].Tags = tags[summaries[i].JobId]
+	}
+	return summaries, nil
+}
+
 func listOutput2JobSummary(output *batch.ListJobsOutput) []JobSummary {
 	js := make([]JobSummary, len(output.JobSummaryList))
 	for i, s := range output.JobSummaryList {
 		js[i] = JobSummary{
-			JobId:        *s.JobId,
-			JobName:      *s.JobName,
-			CreatedAt:    s.CreatedAt,
-			StartedAt:    s.StartedAt,
-			Status:       string(s.Status),
-			StatusDetail: s.StatusReason,
-			StoppedAt:    s.StoppedAt,
-			ResourceName: *s.JobArn,
+			JobId:         *s.JobId,
+			JobName:       *s.JobName,
+			CreatedAt:     s.CreatedAt,
+			StartedAt:     s.StartedAt,
+			Status:        string(s.Status),
+			StatusDetail:  s.StatusReason,
+			StoppedAt:     s.StoppedAt,
+			ResourceName:  *s.JobArn,
+			JobDefinition: aws.ToString(s.JobDefinition),
 		}
 	}
 	return js
@@ -431,21 +768,287 @@ func paramsMapToKvp(params map[string]string) []types.KeyValuePair {
 	return pout
 }
 
-/*
+// Converts a registered AWS Batch job definition back into a Plugin.
+// Secrets are returned as credentials referencing the secret, not the secret value.
+// Fargate Spot is not distinguishable from Fargate in a job definition, so Fargate
+// plugins are returned as FARGATE.
+func jobDefinitionToPlugin(jd types.JobDefinition) (Plugin, error) {
+	cp := jd.ContainerProperties
+	if cp == nil {
+		return Plugin{}, fmt.Errorf("Job definition %s:%d is not a container job definition", aws.ToString(jd.JobDefinitionName), aws.ToInt32(jd.Revision))
+	}
+	plugin := Plugin{
+		Name:               aws.ToString(jd.JobDefinitionName),
+		Revision:           aws.ToInt32(jd.Revision),
+		ImageAndTag:        aws.ToString(cp.Image),
+		Command:            cp.Command,
+		DefaultEnvironment: batchKvpToKvp(cp.Environment),
+		Credentials:        batchSecretsToCreds(cp.Secrets),
+		Parameters:         jd.Parameters,
+		Volumes:            batchToVolumes(cp.Volumes, cp.MountPoints),
+		ComputeEnvironment: batchToComputeEnvironment(cp),
+		PlatformCapability: PlatformEc2,
+	}
+	if jd.RetryStrategy != nil {
+		plugin.RetryAttemts = aws.ToInt32(jd.RetryStrategy.Attempts)
+	}
+	if jd.Timeout != nil && jd.Timeout.AttemptDurationSeconds != nil {
+		timeout := *jd.Timeout.AttemptDurationSeconds
+		plugin.ExecutionTimeout = &timeout
+	}
+	for _, pc := range jd.PlatformCapabilities {
+		if pc == types.PlatformCapabilityFargate {
+			plugin.PlatformCapability = PlatformFargate
+			if cp.NetworkConfiguration != nil {
+				plugin.AssignPublicIp = cp.NetworkConfiguration.AssignPublicIp == types.AssignPublicIpEnabled
+			}
+			if cp.FargatePlatformConfiguration != nil {
+				plugin.PlatformVersion = aws.ToString(cp.FargatePlatformConfiguration.PlatformVersion)
+			}
+		}
+	}
+	return plugin, nil
+}
+
+func batchToComputeEnvironment(cp *types.ContainerProperties) PluginComputeEnvironment {
+	pce := PluginComputeEnvironment{}
+	//legacy job definitions use the deprecated vcpus and memory properties
+	if cp.Vcpus != nil {
+		pce.VCPU = fmt.Sprint(*cp.Vcpus)
+	}
+	if cp.Memory != nil {
+		pce.Memory = fmt.Sprint(*cp.Memory)
+	}
+	for _, rr := range cp.ResourceRequirements {
+		switch rr.Type {
+		case types.ResourceTypeVcpu:
+			pce.VCPU = aws.ToString(rr.Value)
+		case types.ResourceTypeMemory:
+			pce.Memory = aws.ToString(rr.Value)
+		case types.ResourceTypeGpu:
+			pce.GPU = aws.ToString(rr.Value)
+		}
+	}
+	if cp.EphemeralStorage != nil {
+		pce.EphemeralStorage = aws.ToInt32(cp.EphemeralStorage.SizeInGiB)
+	}
+	for _, ul := range cp.Ulimits {
+		pce.Ulimits = append(pce.Ulimits, PluginUlimit{
+			Name:      aws.ToString(ul.Name),
+			SoftLimit: aws.ToInt32(ul.SoftLimit),
+			HardLimit: aws.ToInt32(ul.HardLimit),
+		})
+	}
+	if lp := cp.LinuxParameters; lp != nil {
+		pce.SharedMemory = aws.ToInt32(lp.SharedMemorySize)
+		for _, d := range lp.Devices {
+			perms := make([]string, len(d.Permissions))
+			for i, p := range d.Permissions {
+				perms[i] = string(p)
+			}
+			pce.Devices = append(pce.Devices, PluginDevice{
+				HostPath:      aws.ToString(d.HostPath),
+				ContainerPath: aws.ToString(d.ContainerPath),
+				Permissions:   perms,
+			})
+		}
+	}
+	return pce
+}
+
+// Joins AWS Batch volumes with their mount points.
+// Only the first mount point of a volume is used.
+func batchToVolumes(volumes []types.Volume, mountPoints []types.MountPoint) []PluginComputeVolumes {
+	pvs := []PluginComputeVolumes{}
+	for _, v := range volumes {
+		pv := PluginComputeVolumes{
+			Name: aws.ToString(v.Name),
+		}
+		switch {
+		case v.EfsVolumeConfiguration != nil:
+			efs := v.EfsVolumeConfiguration
+			pv.Type = VolumeTypeEfs
+			pv.ResourceName = aws.ToString(efs.FileSystemId)
+			pv.RootDirectory = aws.ToString(efs.RootDirectory)
+			if efs.AuthorizationConfig != nil {
+				pv.AccessPointId = aws.ToString(efs.AuthorizationConfig.AccessPointId)
+			}
+		case v.Host != nil && v.Host.SourcePath != nil:
+			pv.Type = VolumeTypeHost
+			pv.ResourceName = aws.ToString(v.Host.SourcePath)
+		default:
+			pv.Type = VolumeTypeLocal
+		}
+		for _, mp := range mountPoints {
+			if aws.ToString(mp.SourceVolume) == pv.Name {
+				pv.MountPoint = aws.ToString(mp.ContainerPath)
+				pv.ReadOnly = aws.ToBool(mp.ReadOnly)
+				break
+			}
+		}
+		pvs = append(pvs, pv)
+	}
+	return pvs
+}
+
+func batchKvpToKvp(bkvps []types.KeyValuePair) []KeyValuePair {
+	kvps := make([]KeyValuePair, len(bkvps))
+	for i, bkvp := range bkvps {
+		kvps[i] = KeyValuePair{
+			Name:  aws.ToString(bkvp.Name),
+			Value: aws.ToString(bkvp.Value),
+		}
+	}
+	return kvps
+}
+
+func batchSecretsToCreds(secrets []types.Secret) []KeyValuePair {
+	creds := make([]KeyValuePair, len(secrets))
+	for i, s := range secrets {
+		creds[i] = KeyValuePair{
+			Name:  aws.ToString(s.Name),
+			Value: aws.ToString(s.ValueFrom),
+		}
+	}
+	return creds
+}
+
+// Job definitions only distinguish EC2 and FARGATE.  Fargate Spot is a property of the compute environment.
+func platformToBatch(platform PlatformCapability) types.PlatformCapability {
+	if platform.IsFargate() {
+		return types.PlatformCapabilityFargate
+	}
+	return types.PlatformCapabilityEc2
+}
+
+// Network and platform version configuration for Fargate plugins.  Returns nils for EC2 plugins.
+func fargateConfigToBatch(plugin *Plugin) (*types.NetworkConfiguration, *types.FargatePlatformConfiguration) {
+	if !plugin.Platform().IsFargate() {
+		return nil, nil
+	}
+	assignPublicIp := types.AssignPublicIpDisabled
+	if plugin.AssignPublicIp {
+		assignPublicIp = types.AssignPublicIpEnabled
+	}
+	version := plugin.PlatformVersion
+	if version == "" {
+		version = "LATEST"
+	}
+	return &types.NetworkConfiguration{AssignPublicIp: assignPublicIp},
+		&types.FargatePlatformConfiguration{PlatformVersion: &version}
+}
+
+func resourcesToBatch(pce PluginComputeEnvironment) []types.ResourceRequirement {
+	memory, vcpu := pce.Memory, pce.VCPU
+	rrs := []types.ResourceRequirement{
+		{
+			Type:  types.ResourceTypeMemory,
+			Value: &memory,
+		},
+		{
+			Type:  types.ResourceTypeVcpu,
+			Value: &vcpu,
+		},
+	}
+	if pce.GPU != "" && pce.GPU != "0" {
+		gpu := pce.GPU
+		rrs = append(rrs, types.ResourceRequirement{
+			Type:  types.ResourceTypeGpu,
+			Value: &gpu,
+		})
+	}
+	return rrs
+}
+
+// Shared memory and devices are set through the container linux parameters.
+// Returns nil if neither is used.
+func linuxParametersToBatch(pce PluginComputeEnvironment) *types.LinuxParameters {
+	if pce.SharedMemory == 0 && len(pce.Devices) == 0 {
+		return nil
+	}
+	lp := types.LinuxParameters{}
+	if pce.SharedMemory > 0 {
+		shm := pce.SharedMemory
+		lp.SharedMemorySize = &shm
+	}
+	lp.Devices = make([]types.Device, len(pce.Devices))
+	for i, d := range pce.Devices {
+		hostPath, containerPath := d.HostPath, d.ContainerPath
+		if containerPath == "" {
+			containerPath = hostPath
+		}
+		perms := make([]types.DeviceCgroupPermission, len(d.Permissions))
+		for j, p := range d.Permissions {
+			perms[j] = types.DeviceCgroupPermission(p)
+		}
+		lp.Devices[i] = types.Device{
+			HostPath:      &hostPath,
+			ContainerPath: &containerPath,
+			Permissions:   perms,
+		}
+	}
+	return &lp
+}
+
+func ulimitsToBatch(ulimits []PluginUlimit) []types.Ulimit {
+	buls := make([]types.Ulimit, len(ulimits))
+	for i, ul := range ulimits {
+		name, soft, hard := ul.Name, ul.SoftLimit, ul.HardLimit
+		buls[i] = types.Ulimit{
+			Name:      &name,
+			SoftLimit: &soft,
+			HardLimit: &hard,
+		}
+	}
+	return buls
+}
+
+func ephemeralStorageToBatch(pce PluginComputeEnvironment) *types.EphemeralStorage {
+	if pce.EphemeralStorage == 0 {
+		return nil
+	}
+	size := pce.EphemeralStorage
+	return &types.EphemeralStorage{SizeInGiB: &size}
+}
+
+// Converts plugin volumes into AWS Batch mount points and volumes.
+// Volumes are assumed to have been validated.
 func volumesToBatch(volumes []PluginComputeVolumes) ([]types.MountPoint, []types.Volume) {
 	mps := make([]types.MountPoint, len(volumes))
-	bvs:=make([]types.Volume,len(volumes))
+	bvs := make([]types.Volume, len(volumes))
 	for i, v := range volumes {
+		name, containerPath, readOnly := v.Name, v.ContainerPath(), v.ReadOnly
 		mps[i] = types.MountPoint{
-			ContainerPath: &v.MountPoint,
-			ReadOnly:      &v.ReadOnly,
-			SourceVolume:  &v.ResourceName,
+			ContainerPath: &containerPath,
+			ReadOnly:      &readOnly,
+			SourceVolume:  &name,
 		}
-		bvs[i]=types.Volume{
-			Name: &v.Name,
-			EfsVolumeConfiguration: ,
+		bvs[i] = types.Volume{
+			Name: &name,
+		}
+		resourceName := v.ResourceName
+		switch v.VolumeType() {
+		case VolumeTypeEfs:
+			efs := &types.EFSVolumeConfiguration{
+				FileSystemId: &resourceName,
+			}
+			if v.AccessPointId != "" {
+				//access points require transit encryption
+				accessPoint := v.AccessPointId
+				efs.AuthorizationConfig = &types.EFSAuthorizationConfig{
+					AccessPointId: &accessPoint,
+				}
+				efs.TransitEncryption = types.EFSTransitEncryptionEnabled
+			} else if v.RootDirectory != "" {
+				rootDir := v.RootDirectory
+				efs.RootDirectory = &rootDir
+			}
+			bvs[i].EfsVolumeConfiguration = efs
+		case VolumeTypeHost:
+			bvs[i].Host = &types.Host{
+				SourcePath: &resourceName,
+			}
 		}
 	}
-	return mps
+	return mps, bvs
 }
-*/
@@ -1 +1,182 @@
 package cloudcompute
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/batch/types"
+)
+
+func TestVolumesToBatch(t *testing.T) {
+	volumes := []PluginComputeVolumes{
+		{
+			Name:          "model-library",
+			ResourceName:  "fs-12345678",
+			AccessPointId: "fsap-12345678",
+			ReadOnly:      true,
+		},
+		{
+			Name:         "scratch",
+			Type:         VolumeTypeHost,
+			ResourceName: "/mnt/scratch",
+			MountPoint:   "/scratch",
+		},
+	}
+	mps, bvs := volumesToBatch(volumes)
+	if len(mps) != 2 || len(bvs) != 2 {
+		t.Fatalf("expected 2 mount points and volumes, got %d and %d", len(mps), len(bvs))
+	}
+	if *mps[0].ContainerPath != DefaultMountPoint || !*mps[0].ReadOnly || *mps[0].SourceVolume != "model-library" {
+		t.Errorf("unexpected EFS mount point: %s %t %s", *mps[0].ContainerPath, *mps[0].ReadOnly, *mps[0].SourceVolume)
+	}
+	efs := bvs[0].EfsVolumeConfiguration
+	if efs == nil || *efs.FileSystemId != "fs-12345678" || *efs.AuthorizationConfig.AccessPointId != "fsap-12345678" {
+		t.Fatal("EFS volume configuration was not set")
+	}
+	if efs.TransitEncryption != types.EFSTransitEncryptionEnabled {
+		t.Error("access points require transit encryption")
+	}
+	if bvs[1].Host == nil || *bvs[1].Host.SourcePath != "/mnt/scratch" || *mps[1].ContainerPath != "/scratch" {
+		t.Error("host volume was not mapped")
+	}
+}
+
+func TestComputeEnvironmentToBatch(t *testing.T) {
+	pce := PluginComputeEnvironment{
+		VCPU:         "8",
+		Memory:       "30000",
+		GPU:          "1",
+		SharedMemory: 4096,
+		Ulimits:      []PluginUlimit{{Name: "nofile", SoftLimit: 1024, HardLimit: 4096}},
+		Devices:      []PluginDevice{{HostPath: "/dev/fuse", Permissions: []string{"READ", "WRITE"}}},
+	}
+	rrs := resourcesToBatch(pce)
+	if len(rrs) != 3 || rrs[2].Type != types.ResourceTypeGpu || *rrs[2].Value != "1" {
+		t.Errorf("expected memory, vcpu and gpu resource requirements")
+	}
+	lp := linuxParametersToBatch(pce)
+	if lp == nil || *lp.SharedMemorySize != 4096 || len(lp.Devices) != 1 || *lp.Devices[0].ContainerPath != "/dev/fuse" {
+		t.Errorf("linux parameters were not mapped")
+	}
+	if uls := ulimitsToBatch(pce.Ulimits); len(uls) != 1 || *uls[0].HardLimit != 4096 {
+		t.Errorf("ulimits were not mapped")
+	}
+	if ephemeralStorageToBatch(pce) != nil {
+		t.Errorf("ephemeral storage should not be set")
+	}
+	if linuxParametersToBatch(PluginComputeEnvironment{VCPU: "1", Memory: "2048"}) != nil {
+		t.Errorf("linux parameters should not be set")
+	}
+}
+
+func TestFargateConfigToBatch(t *testing.T) {
+	plugin := Plugin{PlatformCapability: PlatformFargateSpot}
+	if platformToBatch(plugin.Platform()) != types.PlatformCapabilityFargate {
+		t.Errorf("Fargate Spot plugins should register as FARGATE")
+	}
+	network, fargate := fargateConfigToBatch(&plugin)
+	if network == nil || network.AssignPublicIp != types.AssignPublicIpDisabled || *fargate.PlatformVersion != "LATEST" {
+		t.Errorf("unexpected Fargate configuration")
+	}
+	plugin.PlatformCapability = ""
+	if platformToBatch(plugin.Platform()) != types.PlatformCapabilityEc2 {
+		t.Errorf("default platform should be EC2")
+	}
+	if network, fargate = fargateConfigToBatch(&plugin); network != nil || fargate != nil {
+		t.Errorf("EC2 plugins should not have Fargate configuration")
+	}
+}
+
+func TestJobDefinitionToPlugin(t *testing.T) {
+	abp := AwsBatchProvider{executionRole: "arn:aws:iam::123456789012:role/batch"}
+	timeout := int32(1200)
+	plugin := Plugin{
+		Name:               "ras-7",
+		ImageAndTag:        "compute-plugins:ras-linux-7",
+		Command:            []string{"/app/run", "Ref::param1"},
+		DefaultEnvironment: []KeyValuePair{{"RASLIBCMD", "/app/raslib"}},
+		Credentials:        []KeyValuePair{{"AWS_ACCESS_KEY_ID", "arn:aws:secretsmanager:us-east-1:123456789012:secret:s3:AWS_ACCESS_KEY_ID::"}},
+		Parameters:         map[string]string{"param1": "--cmd"},
+		RetryAttemts:       2,
+		ExecutionTimeout:   &timeout,
+		ComputeEnvironment: PluginComputeEnvironment{
+			VCPU:         "4",
+			Memory:       "16000",
+			GPU:          "1",
+			SharedMemory: 2048,
+			Ulimits:      []PluginUlimit{{Name: "nofile", SoftLimit: 1024, HardLimit: 4096}},
+		},
+		Volumes: []PluginComputeVolumes{
+			{Name: "models", Type: VolumeTypeEfs, ResourceName: "fs-1", AccessPointId: "fsap-1", ReadOnly: true, MountPoint: "/data"},
+		},
+	}
+	input, err := abp.pluginToJobDefinition(&plugin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.Parameters != nil || input.RetryStrategy != nil {
+		t.Errorf("expected parameters and retries to be left to job submission: %v %v", input.Parameters, input.RetryStrategy)
+	}
+	//job definitions registered outside of cloudcompute may include parameters and retries
+	jd := types.JobDefinition{
+		JobDefinitionName:    input.JobDefinitionName,
+		Revision:             aws.Int32(7),
+		ContainerProperties:  input.ContainerProperties,
+		Parameters:           map[string]string{"param1": "--cmd"},
+		PlatformCapabilities: input.PlatformCapabilities,
+		RetryStrategy:        &types.RetryStrategy{Attempts: aws.Int32(2)},
+		Timeout:              input.Timeout,
+	}
+	read, err := jobDefinitionToPlugin(jd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plugin.Revision = 7
+	plugin.PlatformCapability = PlatformEc2
+	if !reflect.DeepEqual(plugin, read) {
+		t.Errorf("plugin did not round trip\nexpected: %+v\nreceived: %+v", plugin, read)
+	}
+	if _, err = jobDefinitionToPlugin(types.JobDefinition{JobDefinitionName: aws.String("multinode")}); err == nil {
+		t.Errorf("expected an error for a job definition without container properties")
+	}
+}
+
+func TestSelectPrunableRevisions(t *testing.T) {
+	now := time.Now()
+	jd := func(revision int32, registered time.Time) types.JobDefinition {
+		tags := map[string]string{}
+		if !registered.IsZero() {
+			tags[pluginRegisteredTag] = registered.UTC().Format(time.RFC3339)
+		}
+		return types.JobDefinition{
+			JobDefinitionName: aws.String("ras-7"),
+			JobDefinitionArn:  aws.String(fmt.Sprintf("arn:aws:batch:us-east-1:123456789012:job-definition/ras-7:%d", revision)),
+			Revision:          aws.Int32(revision),
+			Tags:              tags,
+		}
+	}
+	jds := []types.JobDefinition{
+		jd(1, time.Time{}),
+		jd(5, now.Add(-time.Hour)),
+		jd(2, now.Add(-72*time.Hour)),
+		jd(4, now.Add(-2*time.Hour)),
+		jd(3, now.Add(-48*time.Hour)),
+	}
+	inUse := map[string]bool{"arn:aws:batch:us-east-1:123456789012:job-definition/ras-7:2": true}
+
+	prunable, kept := selectPrunableRevisions(jds, 2, time.Time{}, inUse)
+	if len(prunable) != 2 || prunable[0].Revision != 3 || prunable[1].Revision != 1 {
+		t.Errorf("expected revisions 3 and 1 to be pruned, got %v", prunable)
+	}
+	if len(kept) != 1 || kept[0].Revision != 2 {
+		t.Errorf("expected revision 2 to be kept for an active job, got %v", kept)
+	}
+
+	prunable, _ = selectPrunableRevisions(jds, 1, now.Add(-24*time.Hour), nil)
+	if len(prunable) != 3 || prunable[0].Revision != 3 || prunable[1].Revision != 2 || prunable[2].Revision != 1 {
+		t.Errorf("expected revisions older than a day and untagged revisions to be pruned, got %v", prunable)
+	}
+}
@@ -0,0 +1,226 @@
+package cloudcompute
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/usace/cc-go-sdk"
+)
+
+// What backpressure limits
+const (
+	BackpressureJobs   string = "JOBS"
+	BackpressureEvents string = "EVENTS"
+)
+
+// Where backpressure depth is measured
+const (
+	BackpressureScopeCompute string = "COMPUTE"
+	BackpressureScopeQueue   string = "QUEUE"
+)
+
+// QueueSummaryProvider is a compute provider that can list the active jobs in a queue.
+// Backpressure requires the compute provider to implement it (e.g. AwsBatchProvider).
+type QueueSummaryProvider interface {
+	QueueSummary(jobQueue string, query JobsSummaryQuery) error
+}
+
+// Determines if a compute provider can list the active jobs in a queue.
+// Decorators such as the RetryingProvider report the capability of the provider they wrap.
+func SupportsQueueSummary(provider ComputeProvider) bool {
+	if _, ok := provider.(QueueSummaryProvider); !ok {
+		return false
+	}
+	if sp, ok := provider.(interface{ SupportsQueueSummary() bool }); ok {
+		return sp.SupportsQueueSummary()
+	}
+	return true
+}
+
+// BackpressureConfig pauses submission when the number of in-flight jobs or events
+// reaches MaxInFlight and resumes once it drops below LowWatermark.
+//
+// Depth is read from the active jobs in the queue with QueueSummary, so only jobs that are not
+// SUCCEEDED or FAILED are listed.  The COMPUTE scope counts the jobs of the compute.
+// Events are counted by the event ID and the event number tag of the jobs, because the
+// events of an ArrayEventGenerator share an event ID.  Reading the tags requires the
+// provider to describe the active jobs, so an EVENTS depth read costs more than a JOBS read.
+// Between reads the depth is estimated from the jobs submitted, so the provider is only
+// queried when the estimate reaches MaxInFlight.
+type BackpressureConfig struct {
+	//JOBS or EVENTS.  default is JOBS.  An event is in-flight while any of its jobs are not SUCCEEDED or FAILED
+	Limit string
+
+	//COMPUTE or QUEUE.  default is COMPUTE
+	Scope string
+
+	//the depth is read when the estimated depth reaches this value.
+	//submission pauses if the depth is at or above the low watermark
+	MaxInFlight int
+
+	//submission resumes when the depth is below this value.  default is 3/4 of MaxInFlight
+	LowWatermark int
+
+	//time between depth reads while paused.  default is 30 seconds
+	PollInterval time.Duration
+
+	//Optional. called when submission pauses, after each depth read while paused, and when submission resumes
+	Progress func(progress BackpressureProgress)
+}
+
+// Pacing state reported to the backpressure progress callback
+type BackpressureProgress struct {
+	//true while submission is paused
+	Paused bool
+
+	//in-flight jobs or events from the last depth read
+	Depth int
+
+	MaxInFlight  int
+	LowWatermark int
+
+	//events submitted by this run
+	Submitted int
+
+	//time spent paused for the current pause
+	Waited time.Duration
+}
+
+// tracks the estimated depth between reads
+type backpressure struct {
+	config    BackpressureConfig
+	depth     int
+	known     bool
+	submitted int
+}
+
+func newBackpressure(config BackpressureConfig, provider ComputeProvider) (*backpressure, error) {
+	if config.Limit == "" {
+		config.Limit = BackpressureJobs
+	}
+	if config.Scope == "" {
+		config.Scope = BackpressureScopeCompute
+	}
+	if config.Limit != BackpressureJobs && config.Limit != BackpressureEvents {
+		return nil, fmt.Errorf("Invalid backpressure limit %q", config.Limit)
+	}
+	if config.Scope != BackpressureScopeCompute && config.Scope != BackpressureScopeQueue {
+		return nil, fmt.Errorf("Invalid backpressure scope %q", config.Scope)
+	}
+	if config.MaxInFlight <= 0 {
+		return nil, fmt.Errorf("Invalid backpressure: max in-flight must be greater than zero")
+	}
+	if config.LowWatermark <= 0 {
+		config.LowWatermark = config.MaxInFlight * 3 / 4
+		if config.LowWatermark == 0 {
+			config.LowWatermark = 1
+		}
+	}
+	if config.LowWatermark > config.MaxInFlight {
+		return nil, fmt.Errorf("Invalid backpressure: low watermark %d is greater than max in-flight %d", config.LowWatermark, config.MaxInFlight)
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = 30 * time.Second
+	}
+	if !SupportsQueueSummary(provider) {
+		return nil, fmt.Errorf("Invalid backpressure: the compute provider does not support queue summaries")
+	}
+	return &backpressure{config: config}, nil
+}
+
+// amount an event adds to the depth
+func (bp *backpressure) size(event Event) int {
+	if bp.config.Limit == BackpressureEvents {
+		return 1
+	}
+	return len(event.Manifests)
+}
+
+// records a submitted event
+func (bp *backpressure) record(event Event) {
+	bp.depth += bp.size(event)
+	bp.submitted++
+}
+
+// Waits until there is capacity to submit an event.  The wait stops when the context is cancelled.
+func (cc *CloudCompute) waitForCapacity(ctx context.Context, bp *backpressure, event Event) error {
+	config := bp.config
+	if bp.known && bp.depth+bp.size(event) <= config.MaxInFlight {
+		return nil
+	}
+	//once the limit is reached submission waits for the low watermark so the depth
+	//is not read again until another MaxInFlight - LowWatermark events are submitted
+	err := cc.readDepth(bp)
+	if err != nil {
+		return err
+	}
+	if bp.depth < config.LowWatermark {
+		return nil
+	}
+	start := time.Now()
+	progress := func(paused bool) {
+		if config.Progress != nil {
+			config.Progress(BackpressureProgress{
+				Paused:       paused,
+				Depth:        bp.depth,
+				MaxInFlight:  config.MaxInFlight,
+				LowWatermark: config.LowWatermark,
+				Submitted:    bp.submitted,
+				Waited:       time.Since(start),
+			})
+		}
+	}
+	progress(true)
+	for bp.depth >= config.LowWatermark {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(config.PollInterval):
+		}
+		err = cc.readDepth(bp)
+		if err != nil {
+			return err
+		}
+		progress(bp.depth >= config.LowWatermark)
+	}
+	return nil
+}
+
+// reads the number of in-flight jobs or events from the compute provider
+func (cc *CloudCompute) readDepth(bp *backpressure) error {
+	jobs := 0
+	events := make(map[string]bool)
+	prefix := fmt.Sprintf("%s_C_%s_", CcProfile, cc.ID)
+	query := JobsSummaryQuery{
+		IncludeTags: bp.config.Limit == BackpressureEvents,
+		JobSummaryFunction: func(summaries []JobSummary) {
+			for _, s := range summaries {
+				if s.Status == JobStatusSucceeded || s.Status == JobStatusFailed {
+					continue
+				}
+				if bp.config.Scope == BackpressureScopeCompute && !strings.HasPrefix(s.JobName, prefix) {
+					continue
+				}
+				jobs++
+				//job names are {profile}_C_{compute}_E_{event}_M_{manifest}
+				if i := strings.LastIndex(s.JobName, "_M_"); i > 0 {
+					events[s.JobName[:i]+"_N_"+s.Tags[TagEventNumber]] = true
+				} else {
+					events[s.JobId] = true
+				}
+			}
+		},
+	}
+	err := cc.ComputeProvider.(QueueSummaryProvider).QueueSummary(cc.JobQueue, query)
+	if err != nil {
+		return fmt.Errorf("Compute %s: unable to read the %s depth: %w", cc.ID, bp.config.Scope, err)
+	}
+	bp.depth = jobs
+	if bp.config.Limit == BackpressureEvents {
+		bp.depth = len(events)
+	}
+	bp.known = true
+	return nil
+}
@@ -0,0 +1,195 @@
+package cloudcompute
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/usace/cc-go-sdk"
+
+	"github.com/google/uuid"
+)
+
+// depthProvider reports submitted jobs as RUNNING and completes jobs on each queue summary
+type depthProvider struct {
+	testProvider
+	completed       int
+	completePerRead int
+	reads           int
+	statusCalls     int
+}
+
+func (dp *depthProvider) Status(jobQueue string, query JobsSummaryQuery) error {
+	dp.statusCalls++
+	return dp.testProvider.Status(jobQueue, query)
+}
+
+func (dp *depthProvider) QueueSummary(jobQueue string, query JobsSummaryQuery) error {
+	dp.mu.Lock()
+	dp.reads++
+	summaries := make([]JobSummary, len(dp.jobs))
+	for i, job := range dp.jobs {
+		status := "RUNNING"
+		if i < dp.completed {
+			status = JobStatusSucceeded
+		}
+		summaries[i] = JobSummary{JobId: *job.SubmittedJob.JobId, JobName: job.JobName, Status: status}
+		if query.IncludeTags {
+			summaries[i].Tags = job.Tags
+		}
+	}
+	dp.completed += dp.completePerRead
+	if dp.completed > len(dp.jobs) {
+		dp.completed = len(dp.jobs)
+	}
+	dp.mu.Unlock()
+	query.JobSummaryFunction(summaries)
+	return nil
+}
+
+func TestRunBackpressure(t *testing.T) {
+	provider := &depthProvider{completePerRead: 1}
+	maxDepth := 0
+	provider.submitErr = func(job *Job) error {
+		if depth := len(provider.jobs) - provider.completed + 1; depth > maxDepth {
+			maxDepth = depth
+		}
+		return nil
+	}
+	progress := []BackpressureProgress{}
+	cc := CloudCompute{
+		ID:              uuid.New(),
+		Events:          NewEventList(testEvents(10)),
+		ComputeProvider: provider,
+		Backpressure: &BackpressureConfig{
+			MaxInFlight:  3,
+			LowWatermark: 2,
+			PollInterval: time.Millisecond,
+			Progress: func(p BackpressureProgress) {
+				progress = append(progress, p)
+			},
+		},
+	}
+	_, err := cc.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.submitted()) != 10 {
+		t.Fatalf("expected 10 submitted jobs, got %d", len(provider.submitted()))
+	}
+	if maxDepth > 3 {
+		t.Errorf("expected at most 3 in-flight jobs, got %d", maxDepth)
+	}
+	if provider.statusCalls != 0 || provider.reads > 10 {
+		t.Errorf("expected depth reads from queue summaries only: %d status calls, %d reads", provider.statusCalls, provider.reads)
+	}
+	if len(progress) == 0 || !progress[0].Paused || progress[0].Depth != 3 || progress[len(progress)-1].Paused {
+		t.Errorf("unexpected progress %+v", progress)
+	}
+}
+
+func TestRunBackpressureEventsInQueue(t *testing.T) {
+	events := testEvents(4)
+	for i := range events {
+		events[i].Manifests = append(events[i].Manifests, ComputeManifest{ManifestName: "hms", ManifestID: "hms-id", PluginDefinition: "hms"})
+	}
+	provider := &depthProvider{completePerRead: 2}
+	paused := 0
+	cc := CloudCompute{
+		ID:              uuid.New(),
+		Events:          NewEventList(events),
+		ComputeProvider: provider,
+		Backpressure: &BackpressureConfig{
+			Limit:        BackpressureEvents,
+			Scope:        BackpressureScopeQueue,
+			MaxInFlight:  2,
+			PollInterval: time.Millisecond,
+			Progress: func(p BackpressureProgress) {
+				if p.Paused && p.Depth > 2 {
+					t.Errorf("paused at %d events", p.Depth)
+				}
+				paused++
+			},
+		},
+	}
+	_, err := cc.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.submitted()) != 8 || paused == 0 {
+		t.Errorf("expected 8 jobs submitted with pauses: %d jobs, %d progress calls", len(provider.submitted()), paused)
+	}
+}
+
+func TestRunBackpressureArrayEvents(t *testing.T) {
+	writes := 0
+	newStore := newCcStore
+	newCcStore = func(args ...string) (CcStore, error) {
+		return countingStore{writes: &writes}, nil
+	}
+	defer func() { newCcStore = newStore }()
+
+	event := testEvents(1)[0]
+	event.Manifests = append(event.Manifests, ComputeManifest{ManifestName: "hms", ManifestID: "hms-id", PluginDefinition: "hms"})
+	aeg, err := NewArrayEventGenerator(event, 1, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &depthProvider{completePerRead: 2}
+	paused := 0
+	cc := CloudCompute{
+		ID:              uuid.New(),
+		Events:          aeg,
+		ComputeProvider: provider,
+		Backpressure: &BackpressureConfig{
+			Limit:        BackpressureEvents,
+			MaxInFlight:  4,
+			LowWatermark: 3,
+			PollInterval: time.Millisecond,
+			Progress: func(p BackpressureProgress) {
+				if p.Paused {
+					paused++
+				}
+			},
+		},
+	}
+	_, err = cc.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.submitted()) != 16 || paused == 0 {
+		t.Errorf("expected array events to be counted separately: %d jobs, %d pauses", len(provider.submitted()), paused)
+	}
+}
+
+func TestRunBackpressureErrors(t *testing.T) {
+	cc := CloudCompute{
+		ID:              uuid.New(),
+		Events:          NewEventList(testEvents(2)),
+		ComputeProvider: &testProvider{},
+		Backpressure:    &BackpressureConfig{Scope: BackpressureScopeQueue, MaxInFlight: 1},
+	}
+	if _, err := cc.Run(); err == nil {
+		t.Error("expected an error for a queue scope without queue summaries")
+	}
+
+	provider := &testProvider{}
+	cc.ComputeProvider = NewRetryingProvider(RetryingProviderInput{Provider: provider})
+	cc.Backpressure = &BackpressureConfig{MaxInFlight: 1}
+	if _, err := cc.Run(); err == nil || len(provider.submitted()) != 0 {
+		t.Error("expected an error before submission for a wrapped provider without queue summaries")
+	}
+	if !SupportsQueueSummary(NewRetryingProvider(RetryingProviderInput{Provider: &depthProvider{}})) {
+		t.Error("expected a wrapped provider to support queue summaries")
+	}
+
+	cc.ComputeProvider = &depthProvider{}
+	cc.Backpressure = &BackpressureConfig{MaxInFlight: 1, PollInterval: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	cc.Backpressure.Progress = func(p BackpressureProgress) { cancel() }
+	_, err := cc.RunContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the paused run to stop when cancelled, got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package cloudcompute
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+// CheckpointableGenerator is an event generator that can save its position and resume from it.
+// The checkpoint is taken after the last event returned by the generator, so a restored
+// generator continues with the next event.
+type CheckpointableGenerator interface {
+	Checkpoint() ([]byte, error)
+	Restore(checkpoint []byte) error
+}
+
+// CheckpointStore persists a compute checkpoint.
+// Load returns nil without an error when no checkpoint has been saved.
+type CheckpointStore interface {
+	Load() ([]byte, error)
+	Save(checkpoint []byte) error
+}
+
+// Checkpoint settings for a compute run
+type CheckpointConfig struct {
+	//location of the checkpoint
+	Store CheckpointStore
+
+	//number of events submitted between checkpoints.  default is 1.
+	//When the run stops, the position after the last fully submitted event is also saved
+	Interval int
+}
+
+// the persisted checkpoint of a compute
+type computeCheckpoint struct {
+	ComputeID uuid.UUID       `json:"compute_id"`
+	Events    json.RawMessage `json:"events"`
+}
+
+// position of an event generator
+type generatorCheckpoint struct {
+	Generator string          `json:"generator"`
+	Position  int64           `json:"position"`
+	Block     int             `json:"block,omitempty"`
+	Events    json.RawMessage `json:"events,omitempty"`
+}
+
+func encodeCheckpoint(cp generatorCheckpoint) ([]byte, error) {
+	return json.Marshal(cp)
+}
+
+func decodeCheckpoint(generator string, data []byte) (generatorCheckpoint, error) {
+	cp := generatorCheckpoint{}
+	err := json.Unmarshal(data, &cp)
+	if err != nil {
+		return cp, fmt.Errorf("Invalid checkpoint: %s", err)
+	}
+	if cp.Generator != generator {
+		return cp, fmt.Errorf("Invalid checkpoint: checkpoint is for a %s generator not a %s generator", cp.Generator, generator)
+	}
+	return cp, nil
+}
+
+// FileCheckpointStore keeps the checkpoint in a local file.
+// The checkpoint is written to a temporary file and renamed so an interrupted save
+// does not corrupt the previous checkpoint.
+type FileCheckpointStore struct {
+	Path string
+}
+
+func (fcs FileCheckpointStore) Load() ([]byte, error) {
+	data, err := os.ReadFile(fcs.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (fcs FileCheckpointStore) Save(checkpoint []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(fcs.Path), filepath.Base(fcs.Path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(checkpoint)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), fcs.Path)
+}
+
+// restores the event generator from a saved checkpoint of this compute
+func (cc *CloudCompute) restoreCheckpoint() error {
+	data, err := cc.Checkpoint.Store.Load()
+	if err != nil || data == nil {
+		return err
+	}
+	cp := computeCheckpoint{}
+	err = json.Unmarshal(data, &cp)
+	if err != nil {
+		return fmt.Errorf("Invalid compute checkpoint: %s", err)
+	}
+	if cp.ComputeID != cc.ID {
+		return fmt.Errorf("Checkpoint is for compute %s not compute %s", cp.ComputeID, cc.ID)
+	}
+	return cc.Events.(CheckpointableGenerator).Restore(cp.Events)
+}
+
+// saves the checkpoint of an event generator position
+func (cc *CloudCompute) saveCheckpoint(events []byte) error {
+	data, err := json.Marshal(computeCheckpoint{cc.ID, events})
+	if err != nil {
+		return err
+	}
+	err = cc.Checkpoint.Store.Save(data)
+	if err != nil {
+		return fmt.Errorf("Unable to save the checkpoint for compute %s: %w", cc.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package cloudcompute
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func testEvents(n int) []Event {
+	events := make([]Event, n)
+	for i := range events {
+		events[i] = Event{
+			ID:          uuid.New(),
+			EventNumber: int64(i + 1),
+			Manifests:   []ComputeManifest{{ManifestName: "ras", ManifestID: "ras-id", PluginDefinition: "ras"}},
+		}
+	}
+	return events
+}
+
+func TestGeneratorCheckpoints(t *testing.T) {
+	sampling := func() EventGenerator {
+		seg, _ := NewSamplingEventGenerator(SamplingEventGeneratorInput{
+			Event:         sweepEvent(),
+			Samples:       10,
+			Seed:          3,
+			Distributions: []Distribution{{Name: "roughness", Type: DistributionNormal, Mean: 0.03, StdDev: 0.01}},
+		})
+		return seg
+	}
+	blocks := func() EventGenerator {
+		beg, _ := NewBlockEventGenerator(sweepEvent(), []EventBlock{{1, 1, 1, 2}, {1, 2, 3, 5}})
+		return beg
+	}
+	list := func() EventGenerator {
+		return NewEventList(testEvents(5))
+	}
+	filtered := func() EventGenerator {
+		es, _ := ParseEventSet("1, 3-5")
+		return NewEventSetGenerator(NewEventList(testEvents(5)), es)
+	}
+	array := func() EventGenerator {
+		aeg, _ := NewArrayEventGenerator(sweepEvent(), 1, 5)
+		return aeg
+	}
+	stochastic := func() EventGenerator {
+		se, _ := NewStochasticEvents(sweepEvent(), 1, 5)
+		return se
+	}
+	generators := map[string]func() EventGenerator{
+		"array":      array,
+		"stochastic": stochastic,
+		"sampling":   sampling,
+		"blocks":     blocks,
+		"list":       list,
+		"filter":     filtered,
+	}
+	for name, create := range generators {
+		original := create()
+		original.NextEvent()
+		original.NextEvent()
+		checkpoint, err := original.(CheckpointableGenerator).Checkpoint()
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		restored := create()
+		err = restored.(CheckpointableGenerator).Restore(checkpoint)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		for original.HasNextEvent() {
+			a, b := original.NextEvent(), restored.NextEvent()
+			if a.EventNumber != b.EventNumber || a.Vars["roughness"] != b.Vars["roughness"] {
+				t.Errorf("%s: restored generator differs: %d %v, %d %v", name, a.EventNumber, a.Vars, b.EventNumber, b.Vars)
+			}
+		}
+		if restored.HasNextEvent() {
+			t.Errorf("%s: restored generator has extra events", name)
+		}
+	}
+	err := NewEventList(nil).Restore([]byte(`{"generator":"array","position":1}`))
+	if err == nil {
+		t.Error("expected an error restoring a checkpoint from a different generator")
+	}
+}
+
+func TestCsvCheckpointAfterClose(t *testing.T) {
+	path := writeTestFile(t, t.TempDir(), "events.csv", "event_number\n1\n2\n3\n")
+	ceg, _ := NewCsvEventGenerator(FileEventGeneratorInput{Path: path, Event: sweepEvent()})
+	ceg.NextEvent()
+	checkpoint, _ := ceg.Checkpoint()
+	for ceg.HasNextEvent() {
+		ceg.NextEvent()
+	}
+	err := ceg.Restore(checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ceg.HasNextEvent() || ceg.NextEvent().EventNumber != 2 {
+		t.Error("expected the restored generator to continue at event 2")
+	}
+}
+
+func TestRunResumesFromCheckpoint(t *testing.T) {
+	store := FileCheckpointStore{Path: filepath.Join(t.TempDir(), "checkpoint.json")}
+	events := testEvents(5)
+	provider := &testProvider{
+		submitErr: func(job *Job) error {
+			if jobEventNumber(*job) == "4" {
+				return errors.New("throttled")
+			}
+			return nil
+		},
+	}
+	cc := CloudCompute{
+		ID:              uuid.New(),
+		Events:          NewEventList(events),
+		ComputeProvider: provider,
+		Checkpoint:      &CheckpointConfig{Store: store, Interval: 1},
+	}
+	_, err := cc.Run()
+	if err == nil || len(provider.submitted()) != 3 {
+		t.Fatalf("expected the run to fail on event 4 after 3 submissions: %v", err)
+	}
+
+	provider.submitErr = nil
+	cc.Events = NewEventList(events)
+	_, err = cc.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := provider.submitted()
+	if len(jobs) != 5 || jobEventNumber(jobs[3]) != "4" {
+		t.Errorf("expected the restarted run to submit events 4 and 5: %d jobs", len(jobs))
+	}
+
+	other := CloudCompute{ID: uuid.New(), Events: NewEventList(events), ComputeProvider: provider, Checkpoint: &CheckpointConfig{Store: store}}
+	if _, err := other.Run(); err == nil {
+		t.Error("expected an error restoring the checkpoint of a different compute")
+	}
+}
+
+func TestRunCheckpointsSubmittedEventsOnFailure(t *testing.T) {
+	store := FileCheckpointStore{Path: filepath.Join(t.TempDir(), "checkpoint.json")}
+	events := testEvents(6)
+	provider := &testProvider{
+		submitErr: func(job *Job) error {
+			if jobEventNumber(*job) == "5" {
+				return errors.New("throttled")
+			}
+			return nil
+		},
+	}
+	cc := CloudCompute{
+		ID:              uuid.New(),
+		Events:          NewEventList(events),
+		ComputeProvider: provider,
+		Checkpoint:      &CheckpointConfig{Store: store, Interval: 3},
+	}
+	if _, err := cc.Run(); err == nil {
+		t.Fatal("expected the run to fail on event 5")
+	}
+
+	provider.submitErr = nil
+	cc.Events = NewEventList(events)
+	if _, err := cc.Run(); err != nil {
+		t.Fatal(err)
+	}
+	jobs := provider.submitted()
+	if len(jobs) != 6 || jobEventNumber(jobs[4]) != "5" {
+		t.Errorf("expected the restarted run to submit only events 5 and 6: %d jobs", len(jobs))
+	}
+}
@@ -1,8 +1,14 @@
 package cloudcompute
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
 
 	. "github.com/usace/cc-go-sdk"
 
@@ -28,6 +34,20 @@ type CloudCompute struct {
 	//compute provider for the compute (typically AwsBatchProvider)
 	ComputeProvider ComputeProvider `json:"computeProvider"`
 
+	//Optional. persists the event generator position while the compute runs so that a
+	//restarted compute with the same ID resumes submission after the last checkpointed event
+	Checkpoint *CheckpointConfig `json:"-"`
+
+	//Optional. pauses submission while the compute or queue has too many in-flight jobs or events
+	Backpressure *BackpressureConfig `json:"-"`
+
+	//Optional. receives progress callbacks while the compute runs
+	Observer RunObserver `json:"-"`
+
+	//Optional. plugins used by the compute keyed by plugin name.
+	//manifests for plugins with a schema are validated before they are submitted
+	Plugins map[string]Plugin `json:"plugins,omitempty"`
+
 	//map of cloud compute job identifier (manifest id) to submitted job identifier (VendorID) in the compute provider
 	submissionIdMap map[string]string
 }
@@ -47,60 +67,242 @@ type CloudCompute struct {
 */
 
 // Runs a Compute on the ComputeProvider
-func (cc *CloudCompute) Run() error {
+func (cc *CloudCompute) Run() (RunReport, error) {
+	return cc.RunContext(context.Background())
+}
+
+// Runs a Compute on the ComputeProvider until the events are exhausted, the event generator
+// returns an error, or the context is cancelled.  Jobs already submitted are not cancelled
+// when the run stops early.  The report lists the jobs submitted before the run stopped.
+func (cc *CloudCompute) RunContext(ctx context.Context) (RunReport, error) {
+	rr := newRunRecorder(cc.ID, cc.Observer)
+	err := cc.run(ctx, rr)
+	if err != nil && len(rr.report.Failures) == 0 {
+		rr.failed(Event{}, EventSubmission{}, err)
+	}
+	return rr.finish(), err
+}
+
+func (cc *CloudCompute) run(ctx context.Context, rr *runRecorder) error {
 	cc.submissionIdMap = make(map[string]string)
-	for cc.Events.HasNextEvent() {
-		event := cc.Events.NextEvent()
-
-		//go func(event Event) {
-		for _, manifest := range event.Manifests {
-			if len(manifest.Inputs.PayloadAttributes) > 0 || len(manifest.Inputs.DataSources) > 0 {
-				err := manifest.WritePayload() //guarantees the payload id written to the manifest
-				if err != nil {
-					return err
-				}
+	if cc.Checkpoint != nil {
+		if _, ok := cc.Events.(CheckpointableGenerator); !ok {
+			return fmt.Errorf("Compute %s: the event generator does not support checkpoints", cc.ID)
+		}
+		err := cc.restoreCheckpoint()
+		if err != nil {
+			return err
+		}
+	}
+	var bp *backpressure
+	if cc.Backpressure != nil {
+		var err error
+		bp, err = newBackpressure(*cc.Backpressure, cc.ComputeProvider)
+		if err != nil {
+			return err
+		}
+	}
+	source := NewEventSource(cc.Events)
+	cp := runCheckpoint{cc: cc}
+	for {
+		event, err := source.Next(ctx)
+		if err == io.EOF {
+			return cp.save()
+		}
+		if err != nil {
+			cp.saveOnStop()
+			return fmt.Errorf("Compute %s stopped: %w", cc.ID, err)
+		}
+		if bp != nil {
+			err = cc.waitForCapacity(ctx, bp, event)
+			if err != nil {
+				cp.saveOnStop()
+				return fmt.Errorf("Compute %s stopped: %w", cc.ID, err)
 			}
-			env := append(manifest.Inputs.Environment,
-				KeyValuePair{CcPayloadId, manifest.payloadID.String()},
-				KeyValuePair{CcEventID, event.ID.String()})
+		}
+		err = cc.runEvent(event, rr)
+		if err != nil {
+			//the failed event may be partially submitted so the checkpoint is
+			//the position after the last fully submitted event
+			cp.saveOnStop()
+			return err
+		}
+		if bp != nil {
+			bp.record(event)
+		}
+		err = cp.submitted()
+		if err != nil {
+			return err
+		}
+	}
+}
 
-			if !env.HasKey(CcEventNumber) {
-				env = append(env, KeyValuePair{CcEventNumber, fmt.Sprint(event.EventNumber)})
-			}
+// runCheckpoint tracks the generator position after the last fully submitted event
+// and saves it every Interval events and when the run stops.
+type runCheckpoint struct {
+	cc       *CloudCompute
+	position []byte
+	pending  int
+}
 
-			//the manifest substitution is will be removed in future versions.
-			//it is only supported now to ease the transition to payloadId vs manifestId
-			if !env.HasKey(CcManifestId) {
-				env = append(env, KeyValuePair{CcManifestId, manifest.ManifestID})
-			}
+// records the generator position after an event is submitted
+func (rc *runCheckpoint) submitted() error {
+	if rc.cc.Checkpoint == nil {
+		return nil
+	}
+	position, err := rc.cc.Events.(CheckpointableGenerator).Checkpoint()
+	if err != nil {
+		return err
+	}
+	rc.position = position
+	rc.pending++
+	if rc.pending >= rc.cc.Checkpoint.Interval {
+		return rc.save()
+	}
+	return nil
+}
 
-			env = append(env, KeyValuePair{CcPluginDefinition, manifest.PluginDefinition}) //@TODO do we need this?
-			job := Job{
-				JobName:       fmt.Sprintf("%s_C_%s_E_%s_M_%s", CcProfile, cc.ID.String(), event.ID.String(), manifest.ManifestID),
-				JobQueue:      cc.JobQueue,
-				JobDefinition: manifest.PluginDefinition,
-				DependsOn:     cc.mapDependencies(&manifest),
-				Parameters:    manifest.Inputs.Parameters,
-				Tags:          manifest.Tags,
-				RetryAttemts:  manifest.RetryAttemts,
-				JobTimeout:    manifest.JobTimeout,
-				ContainerOverrides: ContainerOverrides{
-					Environment:          env,
-					Command:              manifest.Command,
-					ResourceRequirements: manifest.ResourceRequirements,
-				},
-			}
-			err := cc.ComputeProvider.SubmitJob(&job)
+// saves the position when events were submitted since the last save
+func (rc *runCheckpoint) save() error {
+	if rc.cc.Checkpoint == nil || rc.pending == 0 {
+		return nil
+	}
+	err := rc.cc.saveCheckpoint(rc.position)
+	if err != nil {
+		return err
+	}
+	rc.pending = 0
+	return nil
+}
+
+// saves the position when the run stops with an error.  The run error is returned
+// so a checkpoint error is only logged.
+func (rc *runCheckpoint) saveOnStop() {
+	err := rc.save()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// Submits the jobs for a single event
+func (cc *CloudCompute) runEvent(event Event, rr *runRecorder) error {
+	submission := EventSubmission{EventNumber: event.EventNumber, EventID: event.ID}
+	start := time.Now()
+	rr.eventStarted(event)
+	err := cc.submitEvent(event, rr, &submission)
+	if err != nil {
+		rr.failed(event, submission, err)
+		return err
+	}
+	submission.Duration = time.Since(start)
+	rr.eventCompleted(event, submission)
+	return nil
+}
+
+func (cc *CloudCompute) submitEvent(event Event, rr *runRecorder, submission *EventSubmission) error {
+	unused, err := cc.buildEvent(&event)
+	if err != nil {
+		return err
+	}
+	rr.unusedParameters(unused)
+
+	//go func(event Event) {
+	for _, manifest := range event.Manifests {
+		if manifest.hasPayload() {
+			written, err := manifest.writePayload() //guarantees the payload id written to the manifest
 			if err != nil {
-				return err //@TODO what happens if a set submit ok then one fails?  How do we cancel? See notes below
+				return err
+			}
+			if written {
+				rr.payloadWritten(event, manifest)
 			}
-			cc.submissionIdMap[manifest.ManifestID] = *job.SubmittedJob.JobId
 		}
-		//}(event)
+		job := cc.newJob(event, manifest, cc.mapDependencies(&manifest, cc.submissionIdMap))
+		err := cc.ComputeProvider.SubmitJob(&job)
+		if err != nil {
+			return err //@TODO what happens if a set submit ok then one fails?  How do we cancel? See notes below
+		}
+		cc.submissionIdMap[manifest.ManifestID] = *job.SubmittedJob.JobId
+		rr.manifestSubmitted(event, submission, manifest, *job.SubmittedJob.JobId)
 	}
+	//}(event)
 	return nil
 }
 
+// Orders, renders, and validates an event for submission.
+// Returns the manifest parameters that are not referenced by the commands.
+func (cc *CloudCompute) buildEvent(event *Event) ([]UnusedParameter, error) {
+	err := prepareEvent(event)
+	if err != nil {
+		return nil, err
+	}
+	err = event.Render(cc.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	return cc.validateEvent(event)
+}
+
+// Constructs the compute provider job for a manifest of a built event.
+// The cloud compute identifiers and the event index are injected into the container environment.
+// Plugin default parameters and schema defaults are submitted with the job.
+func (cc *CloudCompute) newJob(event Event, manifest ComputeManifest, dependsOn []JobDependency) Job {
+	//copied so jobs do not share the manifest environment
+	env := append(KeyValuePairs{}, manifest.Inputs.Environment...)
+	params := manifest.Inputs.Parameters
+	if plugin, ok := cc.Plugins[PluginName(manifest.PluginDefinition)]; ok {
+		env = append(env, plugin.schemaEnvironment(env)...)
+		params = plugin.jobParameters(&manifest)
+	}
+	env = append(env,
+		KeyValuePair{CcPayloadId, manifest.payloadID.String()},
+		KeyValuePair{CcEventID, event.ID.String()})
+
+	if !env.HasKey(CcEventNumber) {
+		env = append(env, KeyValuePair{CcEventNumber, fmt.Sprint(event.EventNumber)})
+	}
+
+	//the event number and index are tagged so job status can be reported by event
+	tags := make(map[string]string, len(manifest.Tags)+4)
+	for k, v := range manifest.Tags {
+		tags[k] = v
+	}
+	tags[TagEventNumber] = fmt.Sprint(event.EventNumber)
+	if event.Index != nil {
+		for _, kvp := range event.Index.Environment() {
+			if !env.HasKey(kvp.Name) {
+				env = append(env, kvp)
+			}
+		}
+		for k, v := range event.Index.Tags() {
+			tags[k] = v
+		}
+	}
+
+	//the manifest substitution is will be removed in future versions.
+	//it is only supported now to ease the transition to payloadId vs manifestId
+	if !env.HasKey(CcManifestId) {
+		env = append(env, KeyValuePair{CcManifestId, manifest.ManifestID})
+	}
+
+	env = append(env, KeyValuePair{CcPluginDefinition, manifest.PluginDefinition}) //@TODO do we need this?
+	return Job{
+		JobName:       fmt.Sprintf("%s_C_%s_E_%s_M_%s", CcProfile, cc.ID.String(), event.ID.String(), manifest.ManifestID),
+		JobQueue:      cc.JobQueue,
+		JobDefinition: manifest.PluginDefinition,
+		DependsOn:     dependsOn,
+		Parameters:    params,
+		Tags:          tags,
+		RetryAttemts:  manifest.RetryAttemts,
+		JobTimeout:    manifest.JobTimeout,
+		ContainerOverrides: ContainerOverrides{
+			Environment:          env,
+			Command:              manifest.Command,
+			ResourceRequirements: manifest.ResourceRequirements,
+		},
+	}
+}
+
 /*
 //@Will
 Note: if a manifest submission in an event fails, then what should plan be:
@@ -135,12 +337,57 @@ func (cc *CloudCompute) Cancel(reason string) error {
 	return cc.ComputeProvider.TerminateJobs(input)
 }
 
+// Validates each manifest in the event against the schema of its plugin
+// and checks the command Ref:: placeholders against the parameters submitted with the job.
+// Missing parameters are an error.  Manifest parameters that are not referenced by the
+// command are returned.  Default parameters are not required to be referenced by the command.
+func (cc *CloudCompute) validateEvent(event *Event) ([]UnusedParameter, error) {
+	unused := []UnusedParameter{}
+	for i := range event.Manifests {
+		manifest := &event.Manifests[i]
+		plugin, ok := cc.Plugins[PluginName(manifest.PluginDefinition)]
+		if !ok {
+			continue
+		}
+		err := manifest.ValidateAgainstPlugin(plugin)
+		if err != nil {
+			return nil, fmt.Errorf("Event %d: %w", event.EventNumber, err)
+		}
+		report := plugin.CheckParameters(manifest)
+		for _, u := range report.Unused {
+			if _, ok := manifest.Inputs.Parameters[u]; ok {
+				unused = append(unused, UnusedParameter{event.EventNumber, manifest.ManifestName, u})
+			}
+		}
+		//unused parameters are reported, only missing parameters fail the event
+		report.Unused = nil
+		err = report.Err()
+		if err != nil {
+			return nil, fmt.Errorf("Event %d: Invalid manifest %s: %w", event.EventNumber, manifest.ManifestName, err)
+		}
+	}
+	return unused, nil
+}
+
+// Returns the plugin name from a plugin definition.
+// Accepts "name", "name:revision", or a compute provider resource name (e.g. an AWS job definition ARN)
+func PluginName(pluginDefinition string) string {
+	name := pluginDefinition
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
 // Maps the Dependency identifiers to the compute environment identifiers received from submitted jobs.
-func (cc *CloudCompute) mapDependencies(manifest *ComputeManifest) []JobDependency {
+func (cc *CloudCompute) mapDependencies(manifest *ComputeManifest, jobIds map[string]string) []JobDependency {
 	sdeps := make([]JobDependency, len(manifest.Dependencies))
 	for i, d := range manifest.Dependencies {
-		if sdep, ok := cc.submissionIdMap[d.JobId]; ok {
-			sdeps[i] = JobDependency{sdep}
+		if sdep, ok := jobIds[d.JobId]; ok {
+			sdeps[i] = JobDependency{JobId: sdep}
 		}
 	}
 	return sdeps
@@ -166,6 +413,7 @@ type ComputeManifest struct {
 	JobTimeout           int32                 `yaml:"job_timeout" json:"job_timeout"`
 	ResourceRequirements []ResourceRequirement `yaml:"resource_requirements" json:"resource_requirements"`
 	payloadID            uuid.UUID             `yaml:"-" json:"-"`
+	shared               *sharedPayload
 }
 
 // This is a transitional method that will be removed in a future version
@@ -176,41 +424,104 @@ func (cm *ComputeManifest) GetPayload() uuid.UUID {
 	return cm.payloadID
 }
 
+// Determines if a payload is written for the manifest when it is submitted
+func (cm *ComputeManifest) hasPayload() bool {
+	return cm.shared != nil || len(cm.Inputs.PayloadAttributes) > 0 || len(cm.Inputs.DataSources) > 0
+}
+
 func (cm *ComputeManifest) WritePayload() error {
-	if cm.payloadID == uuid.Nil {
-		payloadId := uuid.New()
-		computeStore, err := NewCcStore(cm.ManifestID, payloadId.String())
-		if err != nil {
-			return err
-		}
-		p := Payload{
-			Attributes: cm.Inputs.PayloadAttributes,
-			Stores:     cm.Stores,
-			Inputs:     cm.Inputs.DataSources,
-			Outputs:    cm.Outputs,
-			Actions:    cm.Actions,
-		}
-		err = computeStore.SetPayload(p)
-		if err != nil {
-			return err
+	_, err := cm.writePayload()
+	return err
+}
+
+// writes the manifest payload if it does not have one.  A manifest with a shared payload
+// writes it the first time one of its copies is submitted and the other copies reuse it.
+// Returns true if a payload was written.
+func (cm *ComputeManifest) writePayload() (bool, error) {
+	if cm.payloadID != uuid.Nil {
+		return false, nil
+	}
+	if cm.shared != nil {
+		cm.shared.mu.Lock()
+		defer cm.shared.mu.Unlock()
+		if cm.shared.id != uuid.Nil {
+			cm.setPayload(cm.shared.id)
+			return false, nil
 		}
-		if cm.Tags == nil {
-			cm.Tags = make(map[string]string)
+	}
+	payloadId := uuid.New()
+	computeStore, err := newCcStore(cm.ManifestID, payloadId.String())
+	if err != nil {
+		return false, err
+	}
+	p := Payload{
+		Attributes: cm.Inputs.PayloadAttributes,
+		Stores:     cm.Stores,
+		Inputs:     cm.Inputs.DataSources,
+		Outputs:    cm.Outputs,
+		Actions:    cm.Actions,
+	}
+	err = computeStore.SetPayload(p)
+	if err != nil {
+		return false, err
+	}
+	cm.setPayload(payloadId)
+	if cm.shared != nil {
+		cm.shared.id = payloadId
+	}
+	return true, nil
+}
+
+// sets the payload id and tag.  The tags are copied so manifests copied from the same
+// template do not share the payload tag.
+func (cm *ComputeManifest) setPayload(payloadId uuid.UUID) {
+	tags := make(map[string]string, len(cm.Tags)+1)
+	for k, v := range cm.Tags {
+		tags[k] = v
+	}
+	tags["payload"] = payloadId.String()
+	cm.Tags = tags
+	cm.payloadID = payloadId
+}
+
+// Marks the manifest payload as shared by every copy of the manifest
+func (cm *ComputeManifest) sharePayload() {
+	if cm.payloadID == uuid.Nil && cm.shared == nil {
+		cm.shared = &sharedPayload{}
+	}
+}
+
+// Clears the payload written for a manifest so that a manifest copied for a single
+// event writes its own payload.  The tags are copied so the shared manifest is not modified.
+func (cm *ComputeManifest) detachPayload() {
+	tags := make(map[string]string, len(cm.Tags))
+	for k, v := range cm.Tags {
+		if k != "payload" {
+			tags[k] = v
 		}
-		cm.Tags["payload"] = payloadId.String()
-		cm.payloadID = payloadId
 	}
-	return nil
+	cm.Tags = tags
+	cm.payloadID = uuid.Nil
+	cm.shared = nil
 }
 
+// a payload written once for the copies of a manifest
+type sharedPayload struct {
+	mu sync.Mutex
+	id uuid.UUID
+}
+
+// replaced in tests
+var newCcStore = NewCcStore
+
 //JobDefinition string            `yaml:"job_definition"`
 
 // Job level inputs that can be injected into a container
 type PluginInputs struct {
-	Environment       KeyValuePairs     `json:"environment"`
-	Parameters        map[string]string `json:"parameters"`
-	DataSources       []DataSource      `json:"dataSources"`
-	PayloadAttributes PayloadAttributes `json:"payloadAttributes"`
+	Environment       KeyValuePairs     `json:"environment" yaml:"environment"`
+	Parameters        map[string]string `json:"parameters" yaml:"parameters"`
+	DataSources       []DataSource      `json:"dataSources" yaml:"data_sources"`
+	PayloadAttributes PayloadAttributes `json:"payloadAttributes" yaml:"payload_attributes"`
 }
 
 /////////////////////////////
@@ -218,9 +529,75 @@ type PluginInputs struct {
 
 // EVENT is a single run through the DAG
 type Event struct {
-	ID          uuid.UUID         `json:"id"`
-	EventNumber int64             `json:"event_number"`
-	Manifests   []ComputeManifest `json:"manifests"`
+	ID          uuid.UUID         `json:"id" yaml:"id"`
+	EventNumber int64             `json:"event_number" yaml:"event_number"`
+	Manifests   []ComputeManifest `json:"manifests" yaml:"manifests"`
+
+	//Optional. custom variables available to manifest templates as {{.Vars.name}}
+	Vars map[string]string `json:"vars,omitempty" yaml:"vars,omitempty"`
+
+	//Optional. position of the event in a realization / block / event hierarchy
+	Index *EventIndex `json:"index,omitempty" yaml:"index,omitempty"`
+}
+
+// Creates a new event from a set of manifests.
+// Manifests without a ManifestID are assigned one and dependencies declared
+// by manifest name are resolved to manifest IDs.
+func NewEvent(eventNumber int64, manifests ...ComputeManifest) (Event, error) {
+	event := Event{
+		ID:          uuid.New(),
+		EventNumber: eventNumber,
+		Manifests:   manifests,
+	}
+	err := event.ResolveDependencies()
+	return event, err
+}
+
+// Assigns a uuid to any manifest without a ManifestID and maps dependencies declared
+// by ManifestName to the upstream ManifestID.  Returns an error listing every duplicate
+// manifest name and every dependency that does not resolve to a manifest in the event.
+func (e *Event) ResolveDependencies() error {
+	errs := ValidationErrors{}
+	ids := make(map[string]bool)
+	names := make(map[string]string)
+	for i := range e.Manifests {
+		m := &e.Manifests[i]
+		if m.ManifestID == "" {
+			m.ManifestID = uuid.NewString()
+		}
+		ids[m.ManifestID] = true
+		if m.ManifestName != "" {
+			if _, ok := names[m.ManifestName]; ok {
+				errs = append(errs, fmt.Sprintf("duplicate manifest name %s", m.ManifestName))
+			}
+			names[m.ManifestName] = m.ManifestID
+		}
+	}
+	for i := range e.Manifests {
+		m := &e.Manifests[i]
+		for j := range m.Dependencies {
+			d := &m.Dependencies[j]
+			if d.ManifestName != "" {
+				id, ok := names[d.ManifestName]
+				switch {
+				case !ok:
+					errs = append(errs, fmt.Sprintf("manifest %s depends on unknown manifest name %s", m.ManifestName, d.ManifestName))
+				case d.JobId != "" && d.JobId != id:
+					errs = append(errs, fmt.Sprintf("manifest %s dependency on %s does not match manifest id %s", m.ManifestName, d.ManifestName, d.JobId))
+				default:
+					d.JobId = id
+				}
+				continue
+			}
+			if !ids[d.JobId] {
+				errs = append(errs, fmt.Sprintf("manifest %s depends on unknown manifest id %q", m.ManifestName, d.JobId))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("Invalid dependencies in event %d: %w", e.EventNumber, errs)
+	}
+	return nil
 }
 
 // Adds a manifest to the Event
@@ -243,8 +620,8 @@ func (e *Event) AddManifestAt(m ComputeManifest, i int) {
 // For example when using AWS Batch: "AWS_ACCESS_KEY_ID", "arn:aws:secretsmanager:us-east-1:01010101010:secret:mysecret:AWS_ACCESS_KEY_ID::
 type Plugin struct {
 	//ID                 uuid.UUID
-	Name string `json:"name" yaml:"name"`
-	//Revision           string                   `json:"revision" yaml:"revision"`
+	Name               string                   `json:"name" yaml:"name"`
+	Revision           int32                    `json:"revision,omitempty" yaml:"revision,omitempty"` //set when read from the compute provider.  ignored on registration
 	ImageAndTag        string                   `json:"image_and_tag" yaml:"image_and_tag"`
 	Description        string                   `json:"description" yaml:"description"`
 	Command            []string                 `json:"command" yaml:"command"`
@@ -252,21 +629,113 @@ type Plugin struct {
 	DefaultEnvironment []KeyValuePair           `json:"environment" yaml:"environment"` //default values for the container environment
 	Volumes            []PluginComputeVolumes   `json:"volumes" yaml:"volumes"`
 	Credentials        []KeyValuePair           `json:"credentials" yaml:"credentials"`
-	Parameters         map[string]string        `json:"parameters" yaml:"parameters"`
+	Parameters         map[string]string        `json:"parameters" yaml:"parameters"` //default parameters submitted with each job
 	RetryAttemts       int32                    `json:"retry_attempts" yaml:"retry_attempts"`
 	ExecutionTimeout   *int32                   `json:"execution_timeout" yaml:"execution_timeout"`
+	PlatformCapability PlatformCapability       `json:"platform_capability,omitempty" yaml:"platform_capability"` //default is EC2
+	AssignPublicIp     bool                     `json:"assign_public_ip,omitempty" yaml:"assign_public_ip"`       //Fargate only
+	PlatformVersion    string                   `json:"platform_version,omitempty" yaml:"platform_version"`       //Fargate only. default is "LATEST"
+	Schema             *PluginSchema            `json:"schema,omitempty" yaml:"schema,omitempty"`                 //optional manifest input schema.  not registered with the compute provider
+}
+
+// The platform a plugin is able to run on.
+// FARGATE_SPOT plugins use the same job definition as FARGATE and
+// should be submitted to a queue backed by a Fargate Spot compute environment
+type PlatformCapability string
+
+const (
+	PlatformEc2         PlatformCapability = "EC2"
+	PlatformFargate     PlatformCapability = "FARGATE"
+	PlatformFargateSpot PlatformCapability = "FARGATE_SPOT"
+)
+
+// returns the plugin platform, defaulting to EC2
+func (p *Plugin) Platform() PlatformCapability {
+	if p.PlatformCapability == "" {
+		return PlatformEc2
+	}
+	return p.PlatformCapability
+}
+
+func (pc PlatformCapability) IsFargate() bool {
+	return pc == PlatformFargate || pc == PlatformFargateSpot
 }
 
 type PluginComputeEnvironment struct {
-	VCPU   string `json:"vcpu" yaml:"vcpu"`
-	Memory string `json:"memory" yaml:"memory"`
+	VCPU             string         `json:"vcpu" yaml:"vcpu"`
+	Memory           string         `json:"memory" yaml:"memory"` //MiB
+	GPU              string         `json:"gpu,omitempty" yaml:"gpu"`
+	EphemeralStorage int32          `json:"ephemeral_storage,omitempty" yaml:"ephemeral_storage"` //GiB. Fargate only
+	SharedMemory     int32          `json:"shared_memory,omitempty" yaml:"shared_memory"`         //MiB. size of /dev/shm
+	Ulimits          []PluginUlimit `json:"ulimits,omitempty" yaml:"ulimits"`
+	Devices          []PluginDevice `json:"devices,omitempty" yaml:"devices"`
+}
+
+// Resource limit for the container.  e.g. "nofile"
+type PluginUlimit struct {
+	Name      string `json:"name" yaml:"name"`
+	SoftLimit int32  `json:"soft_limit" yaml:"soft_limit"`
+	HardLimit int32  `json:"hard_limit" yaml:"hard_limit"`
 }
 
+// Host device exposed to the container.
+// Permissions are any of READ, WRITE, or MKNOD.  Default is all three.
+type PluginDevice struct {
+	HostPath      string   `json:"host_path" yaml:"host_path"`
+	ContainerPath string   `json:"container_path,omitempty" yaml:"container_path"` //default is the host path
+	Permissions   []string `json:"permissions,omitempty" yaml:"permissions"`
+}
+
+type VolumeType string
+
+const (
+	VolumeTypeEfs   VolumeType = "EFS"
+	VolumeTypeHost  VolumeType = "HOST"
+	VolumeTypeLocal VolumeType = "LOCAL" //scratch volume managed by the container runtime
+
+	DefaultMountPoint string = "/data"
+)
+
+// PluginComputeVolumes are volumes mounted into the plugin container.
+// For EFS volumes the ResourceName is the file system id (e.g. fs-12345678)
+// For HOST volumes the ResourceName is the path on the host instance
+// LOCAL volumes do not use a ResourceName
 type PluginComputeVolumes struct {
-	Name         string `json:"name" yaml:"name"`
-	ResourceName string `json:"resource_name" yaml:"resource_name"`
-	ReadOnly     bool   `json:"read_only" yaml:"read_only"`
-	MountPoint   string `json:"mount_point" yaml:"mount_point"` //default is "/data"
+	Name          string     `json:"name" yaml:"name"`
+	Type          VolumeType `json:"type" yaml:"type"` //default is "EFS"
+	ResourceName  string     `json:"resource_name" yaml:"resource_name"`
+	AccessPointId string     `json:"access_point_id,omitempty" yaml:"access_point_id"` //optional EFS access point
+	RootDirectory string     `json:"root_directory,omitempty" yaml:"root_directory"`   //optional EFS root directory. must be empty or "/" when using an access point
+	ReadOnly      bool       `json:"read_only" yaml:"read_only"`
+	MountPoint    string     `json:"mount_point" yaml:"mount_point"` //default is "/data"
+}
+
+// returns the volume type, defaulting to EFS
+func (v PluginComputeVolumes) VolumeType() VolumeType {
+	if v.Type == "" {
+		return VolumeTypeEfs
+	}
+	return v.Type
+}
+
+// returns the container mount point, defaulting to "/data"
+func (v PluginComputeVolumes) ContainerPath() string {
+	if v.MountPoint == "" {
+		return DefaultMountPoint
+	}
+	return v.MountPoint
+}
+
+// Filter for listing plugins registered in a compute provider
+type PluginFilter struct {
+	//Optional. Only list revisions of this plugin name
+	Name string
+
+	//Optional. ACTIVE or INACTIVE.  Default is ACTIVE
+	Status string
+
+	//Only return the latest revision of each plugin
+	LatestOnly bool
 }
 
 type PluginRegistrationOutput struct {
@@ -275,5 +744,47 @@ type PluginRegistrationOutput struct {
 	Revision     int32
 }
 
+// Output of an EnsurePlugin request.
+// If the plugin matched the latest registered revision, Registered is false and
+// the registration output refers to the existing revision.
+type EnsurePluginOutput struct {
+	PluginRegistrationOutput
+
+	//true if a new revision was registered
+	Registered bool
+
+	//differences between the latest registered revision and the plugin.
+	//empty if the plugin was unchanged or registered for the first time
+	Changes []PluginChange
+}
+
+// A single difference between a registered plugin and a requested plugin
+type PluginChange struct {
+	Field    string
+	Previous string
+	Current  string
+}
+
+// PluginManifest describes the manifest inputs a plugin expects.
+// It is read from the manifest section of a plugin definition file.
 type PluginManifest struct {
+	ExecutionTimeout string               `json:"execution_timeout" yaml:"execution_timeout"`
+	RetryAttempts    string               `json:"retry_attempts" yaml:"retry_attempts"`
+	Inputs           PluginManifestInputs `json:"inputs" yaml:"inputs"`
+}
+
+type PluginManifestInputs struct {
+	Environment SchemaFields       `json:"environment" yaml:"environment"`
+	Parameters  SchemaFields       `json:"parameters" yaml:"parameters"`
+	Command     []string           `json:"command" yaml:"command"`
+	DataSources []SchemaDataSource `json:"data_sources" yaml:"data_sources"`
+}
+
+// Returns the input schema declared by the manifest
+func (pm PluginManifest) Schema() PluginSchema {
+	return PluginSchema{
+		Environment: pm.Inputs.Environment,
+		Parameters:  pm.Inputs.Parameters,
+		DataSources: pm.Inputs.DataSources,
+	}
 }
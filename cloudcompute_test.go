@@ -1 +1,73 @@
 package cloudcompute
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestNewEventResolvesDependencies(t *testing.T) {
+	event, err := NewEvent(1,
+		ComputeManifest{ManifestName: "ras", ManifestID: "ras-id"},
+		ComputeManifest{ManifestName: "consequences", Dependencies: []JobDependency{{ManifestName: "ras"}}},
+		ComputeManifest{ManifestName: "timing", Dependencies: []JobDependency{{ManifestName: "ras"}, {ManifestName: "consequences"}}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := event.Manifests
+	if m[1].ManifestID == "" || m[2].ManifestID == "" || m[1].ManifestID == m[2].ManifestID {
+		t.Fatal("expected unique manifest ids to be assigned")
+	}
+	if m[1].Dependencies[0].JobId != "ras-id" || m[2].Dependencies[1].JobId != m[1].ManifestID {
+		t.Errorf("dependencies were not resolved: %v %v", m[1].Dependencies, m[2].Dependencies)
+	}
+}
+
+func TestResolveDependenciesErrors(t *testing.T) {
+	event := Event{
+		Manifests: []ComputeManifest{
+			{ManifestName: "ras", ManifestID: "ras-id"},
+			{ManifestName: "timing", Dependencies: []JobDependency{{ManifestName: "rass"}, {JobId: "missing-id"}, {JobId: "other-id", ManifestName: "ras"}}},
+		},
+	}
+	err := event.ResolveDependencies()
+	if err == nil {
+		t.Fatal("expected unresolved dependency errors")
+	}
+	for _, expected := range []string{"rass", "missing-id", "other-id"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected the error to reference %s: %s", expected, err)
+		}
+	}
+
+	duplicate := Event{
+		Manifests: []ComputeManifest{
+			{ManifestName: "ras", ManifestID: "ras-1"},
+			{ManifestName: "ras", ManifestID: "ras-2"},
+			{ManifestName: "timing", Dependencies: []JobDependency{{ManifestName: "ras"}}},
+		},
+	}
+	err = duplicate.ResolveDependencies()
+	if err == nil || !strings.Contains(err.Error(), "duplicate manifest name ras") {
+		t.Errorf("expected a duplicate manifest name error, got %v", err)
+	}
+}
+
+func TestJobDependencyEncoding(t *testing.T) {
+	dep := JobDependency{JobId: "ras-id", ManifestName: "ras"}
+	data, err := json.Marshal(dep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"job_id":"ras-id","manifest_name":"ras"}` {
+		t.Errorf("unexpected json %s", data)
+	}
+	decoded := JobDependency{}
+	err = yaml.Unmarshal(data, &decoded)
+	if err != nil || decoded != dep {
+		t.Errorf("expected the json field names to decode as yaml: %+v %v", decoded, err)
+	}
+}
@@ -3,6 +3,7 @@ package cloudcompute
 import (
 	"errors"
 	"regexp"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -55,6 +56,34 @@ type TerminateJobOutput struct {
 	JobId string
 }
 
+// Input for removing old revisions of a plugin from a compute provider
+type PrunePluginsInput struct {
+	//Plugin name to prune
+	Name string
+
+	//Number of most recent revisions to keep.  Must be at least one
+	KeepLatest int
+
+	//Optional. Only prune revisions registered longer than this duration ago
+	OlderThan time.Duration
+
+	//Optional. Job queues to check for active jobs.  Revisions used by active jobs are kept
+	JobQueues []string
+
+	//Report the revisions that would be removed without removing them
+	DryRun bool
+}
+
+type PrunePluginsOutput struct {
+	//Revisions that were removed, or would be removed in a dry run
+	Removed []PluginRegistrationOutput
+
+	//Revisions that would have been removed but are used by active jobs
+	InUse []PluginRegistrationOutput
+
+	DryRun bool
+}
+
 // function to process the results of each job termination
 type TerminateJobFunction func(output TerminateJobOutput)
 
@@ -65,7 +94,11 @@ type ComputeProvider interface {
 	Status(jobQueue string, query JobsSummaryQuery) error
 	JobLog(submittedJobId string) ([]string, error)
 	RegisterPlugin(plugin *Plugin) (PluginRegistrationOutput, error)
+	EnsurePlugin(plugin *Plugin) (EnsurePluginOutput, error)
+	GetPlugin(nameAndRevision string) (Plugin, error)
+	ListPlugins(filter PluginFilter) ([]Plugin, error)
 	UnregisterPlugin(nameAndRevision string) error
+	PrunePlugins(input PrunePluginsInput) (PrunePluginsOutput, error)
 }
 
 // Overrides the container command or environment from the base values
@@ -99,13 +132,18 @@ type Job struct {
 }
 
 // JobDependency is a graph dependency relationship.
-// When created for a manifest, the JobId value should be the manifestId. When a Compute
+// When created for a manifest, the JobId value should be the manifestId or the JobId
+// can be left empty and the upstream manifest referenced by ManifestName. Names are
+// resolved to manifestIds when the event is constructed. When a Compute
 // is run, Compute will map manifestIds to submitted JobIds as they are submitted and
 // handle the dependency mapping for the compute environment
 type JobDependency struct {
 	//Cloud Compute Job Identifier
 	//should be ManifestID when being added as a dependency in a Manifest
-	JobId string
+	JobId string `json:"job_id" yaml:"job_id,omitempty"`
+
+	//Optional. Name of the upstream manifest in the same event
+	ManifestName string `json:"manifest_name,omitempty" yaml:"manifest_name,omitempty"`
 }
 
 type VendorJob interface {
@@ -153,6 +191,12 @@ type JobSummary struct {
 
 	//Compute Vendor resource name for the job.  e.g. the Job ARN for AWS
 	ResourceName string
+
+	//Compute Vendor resource name for the plugin revision used by the job.  e.g. the Job Definition ARN for AWS
+	JobDefinition string
+
+	//job tags.  only set when the query includes tags
+	Tags map[string]string
 }
 
 func (js JobSummary) ID() string {
@@ -175,6 +219,30 @@ type JobsSummaryQuery struct {
 
 	//a required function to process each job returned in the query
 	JobSummaryFunction JobSummaryFunction
+
+	//Optional. include the job tags in the summaries.
+	//AWS requires an additional DescribeJobs request for each page of jobs
+	IncludeTags bool
+
+	//Optional. makes each page request of the summary.  Providers that report PagesSummaries
+	//call it with the request for each page, so a decorator can rate limit and retry single pages.
+	//The request can be called more than once for the same page.
+	PageRequest func(request func() error) error
+}
+
+// makes a page request through the PageRequest of the query
+func (q JobsSummaryQuery) requestPage(request func() error) error {
+	if q.PageRequest == nil {
+		return request()
+	}
+	return q.PageRequest(request)
+}
+
+// Determines if a compute provider makes each Status and QueueSummary page request
+// through the JobsSummaryQuery PageRequest
+func pagesSummaries(provider ComputeProvider) bool {
+	pp, ok := provider.(interface{ PagesSummaries() bool })
+	return ok && pp.PagesSummaries()
 }
 
 type JobNameParts struct {
@@ -0,0 +1,327 @@
+package cloudcompute
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	GeneratorList           string = "list"
+	GeneratorArray          string = "array"
+	GeneratorStochastic     string = "stochastic"
+	GeneratorSweep          string = "sweep"
+	GeneratorLatinHypercube string = "latin-hypercube"
+	GeneratorSobol          string = "sobol"
+	GeneratorCsv            string = "csv"
+	GeneratorJsonLines      string = "jsonl"
+	GeneratorDirectory      string = "directory"
+	GeneratorBlocks         string = "blocks"
+
+	ProviderAwsBatch string = "aws-batch"
+)
+
+// ComputeSpec is a complete compute definition that can be kept in a YAML or JSON file
+type ComputeSpec struct {
+	//User friendly Name for the compute
+	Name string `json:"name" yaml:"name"`
+
+	//JobQueue to push the events to
+	JobQueue string `json:"job_queue" yaml:"job_queue"`
+
+	//compute provider configuration
+	Provider ProviderConfig `json:"provider" yaml:"provider"`
+
+	//Optional. plugin definition files used to validate the manifests.
+	//relative paths are relative to the compute spec file
+	Plugins []string `json:"plugins" yaml:"plugins"`
+
+	Generator GeneratorSpec `json:"generator" yaml:"generator"`
+
+	//Optional. infer manifest dependencies from matching data source outputs and inputs
+	//and merge them with the declared dependencies
+	InferDependencies bool `json:"infer_dependencies" yaml:"infer_dependencies"`
+
+	//manifests making up the DAG for each event
+	Manifests []ManifestSpec `json:"manifests" yaml:"manifests"`
+
+	//directory relative paths are resolved against
+	baseDir string
+}
+
+// Configuration for creating a compute provider.
+// The only supported type is "aws-batch"
+type ProviderConfig struct {
+	Type          string `json:"type" yaml:"type"`
+	Region        string `json:"region" yaml:"region"`
+	Profile       string `json:"profile" yaml:"profile"`
+	ExecutionRole string `json:"execution_role" yaml:"execution_role"`
+}
+
+// Event generator definition.
+// list generators produce one event for each event number in Events.
+// array and stochastic generators produce events for the range Start to End inclusive.
+// sweep generators produce one event for each parameter set of the sweep numbered from Start.
+// latin-hypercube and sobol generators produce Samples events numbered from Start.
+// csv, jsonl and directory generators stream events from the file or directory at Path.
+// blocks generators produce the events of the realization Blocks, or of the JSON blocks file at Path.
+type GeneratorSpec struct {
+	Type          string              `json:"type" yaml:"type"`
+	Start         int64               `json:"start" yaml:"start"`
+	End           int64               `json:"end" yaml:"end"`
+	Events        []int64             `json:"events" yaml:"events"`
+	Mode          SweepMode           `json:"mode" yaml:"mode"`
+	Axes          []SweepAxis         `json:"axes" yaml:"axes"`
+	Sets          []map[string]string `json:"sets" yaml:"sets"`
+	Samples       int64               `json:"samples" yaml:"samples"`
+	Seed          int64               `json:"seed" yaml:"seed"`
+	Distributions []Distribution      `json:"distributions" yaml:"distributions"`
+	Path          string              `json:"path" yaml:"path"`
+	Blocks        []EventBlock        `json:"blocks" yaml:"blocks"`
+}
+
+// ManifestSpec is a compute manifest with dependencies declared by manifest name
+type ManifestSpec struct {
+	ComputeManifest `yaml:",inline"`
+	DependsOn       []string `json:"depends_on" yaml:"depends_on"`
+}
+
+// Creates a compute provider from the provider configuration
+func NewComputeProvider(config ProviderConfig) (ComputeProvider, error) {
+	switch strings.ToLower(config.Type) {
+	case ProviderAwsBatch, "":
+		return NewAwsBatchProvider(AwsBatchProviderInput{
+			ExecutionRole: config.ExecutionRole,
+			BatchRegion:   config.Region,
+			ConfigProfile: config.Profile,
+		})
+	}
+	return nil, fmt.Errorf("Unsupported compute provider type: %s", config.Type)
+}
+
+// Loads a compute spec from a file.  Files with a .json extension are read as JSON,
+// all others as YAML.
+func LoadComputeSpec(path string) (*ComputeSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec := ComputeSpec{}
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(data, &spec)
+	} else {
+		err = yaml.Unmarshal(data, &spec)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid compute spec %s: %s", path, err)
+	}
+	spec.baseDir = filepath.Dir(path)
+	err = spec.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid compute spec %s: %s", path, err)
+	}
+	return &spec, nil
+}
+
+// Validates the compute spec
+func (cs *ComputeSpec) Validate() error {
+	errs := ValidationErrors{}
+	if cs.Name == "" {
+		errs = append(errs, "missing compute name")
+	}
+	if cs.JobQueue == "" {
+		errs = append(errs, "missing job queue")
+	}
+	switch cs.Generator.Type {
+	case GeneratorList:
+		if len(cs.Generator.Events) == 0 {
+			errs = append(errs, "list generator has no events")
+		}
+	case GeneratorArray, GeneratorStochastic:
+		if cs.Generator.End < cs.Generator.Start {
+			errs = append(errs, fmt.Sprintf("generator end %d is before start %d", cs.Generator.End, cs.Generator.Start))
+		}
+	case GeneratorSweep:
+		if len(cs.Generator.Axes) == 0 && len(cs.Generator.Sets) == 0 {
+			errs = append(errs, "sweep generator has no axes or parameter sets")
+		}
+	case GeneratorCsv, GeneratorJsonLines, GeneratorDirectory:
+		if cs.Generator.Path == "" {
+			errs = append(errs, fmt.Sprintf("%s generator is missing a path", cs.Generator.Type))
+		}
+	case GeneratorBlocks:
+		if len(cs.Generator.Blocks) == 0 && cs.Generator.Path == "" {
+			errs = append(errs, "blocks generator has no blocks or blocks file")
+		}
+	case GeneratorLatinHypercube, GeneratorSobol:
+		if cs.Generator.Samples <= 0 {
+			errs = append(errs, fmt.Sprintf("%s generator samples must be positive", cs.Generator.Type))
+		}
+		for _, d := range cs.Generator.Distributions {
+			err := d.Validate()
+			if err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("unsupported generator type %q", cs.Generator.Type))
+	}
+	if len(cs.Manifests) == 0 {
+		errs = append(errs, "no manifests")
+	}
+	names := make(map[string]bool)
+	for _, m := range cs.Manifests {
+		if m.ManifestName == "" {
+			errs = append(errs, "manifest is missing a name")
+			continue
+		}
+		if names[m.ManifestName] {
+			errs = append(errs, fmt.Sprintf("duplicate manifest name %s", m.ManifestName))
+		}
+		names[m.ManifestName] = true
+		if m.PluginDefinition == "" {
+			errs = append(errs, fmt.Sprintf("manifest %s is missing a plugin definition", m.ManifestName))
+		}
+	}
+	for _, m := range cs.Manifests {
+		for _, d := range m.DependsOn {
+			if !names[d] {
+				errs = append(errs, fmt.Sprintf("manifest %s depends on unknown manifest %s", m.ManifestName, d))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Builds the event template from the spec manifests.
+// Manifests without an ID are assigned one and name dependencies are resolved to manifest IDs.
+// When InferDependencies is set, dependencies implied by the data flow are added.
+// The manifests are ordered so every manifest follows its dependencies.
+func (cs *ComputeSpec) Event() (Event, error) {
+	manifests := make([]ComputeManifest, len(cs.Manifests))
+	for i, m := range cs.Manifests {
+		manifests[i] = m.ComputeManifest
+		manifests[i].Dependencies = append([]JobDependency{}, m.Dependencies...)
+		for _, d := range m.DependsOn {
+			manifests[i].Dependencies = append(manifests[i].Dependencies, JobDependency{ManifestName: d})
+		}
+	}
+	event, err := NewEvent(0, manifests...)
+	if err != nil {
+		return event, err
+	}
+	if cs.InferDependencies {
+		_, err = event.InferDependencies()
+		if err != nil {
+			return event, err
+		}
+	}
+	return event, event.OrderManifests()
+}
+
+// Creates the event generator declared in the spec.
+// Array and stochastic generators share the manifest payloads between events.  The shared
+// payloads are written when the first event is submitted.
+func (cs *ComputeSpec) EventGenerator() (EventGenerator, error) {
+	event, err := cs.Event()
+	if err != nil {
+		return nil, err
+	}
+	switch cs.Generator.Type {
+	case GeneratorList:
+		events := make([]Event, len(cs.Generator.Events))
+		for i, en := range cs.Generator.Events {
+			e := event
+			e.ID = uuid.New()
+			e.EventNumber = en
+			events[i] = e
+		}
+		return NewEventList(events), nil
+	case GeneratorArray:
+		return NewArrayEventGenerator(event, cs.Generator.Start, cs.Generator.End)
+	case GeneratorStochastic:
+		return NewStochasticEvents(event, cs.Generator.Start, cs.Generator.End)
+	case GeneratorSweep:
+		return NewSweepEventGenerator(SweepEventGeneratorInput{
+			Event:            event,
+			Mode:             cs.Generator.Mode,
+			Axes:             cs.Generator.Axes,
+			Sets:             cs.Generator.Sets,
+			StartEventNumber: cs.Generator.Start,
+		})
+	case GeneratorLatinHypercube, GeneratorSobol:
+		return NewSamplingEventGenerator(SamplingEventGeneratorInput{
+			Event:            event,
+			Method:           SamplingMethod(cs.Generator.Type),
+			Distributions:    cs.Generator.Distributions,
+			Samples:          cs.Generator.Samples,
+			Seed:             cs.Generator.Seed,
+			StartEventNumber: cs.Generator.Start,
+		})
+	case GeneratorCsv:
+		return NewCsvEventGenerator(FileEventGeneratorInput{Path: cs.path(cs.Generator.Path), Event: event})
+	case GeneratorJsonLines:
+		return NewJsonLinesEventGenerator(FileEventGeneratorInput{Path: cs.path(cs.Generator.Path), Event: event})
+	case GeneratorDirectory:
+		return NewDirectoryEventGenerator(cs.path(cs.Generator.Path), 0)
+	case GeneratorBlocks:
+		blocks := cs.Generator.Blocks
+		if len(blocks) == 0 {
+			blocks, err = LoadEventBlocks(cs.path(cs.Generator.Path))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return NewBlockEventGenerator(event, blocks)
+	}
+	return nil, fmt.Errorf("Unsupported generator type %q", cs.Generator.Type)
+}
+
+// Builds a ready to run CloudCompute using a compute provider created from the spec provider config
+func (cs *ComputeSpec) CloudCompute() (*CloudCompute, error) {
+	provider, err := NewComputeProvider(cs.Provider)
+	if err != nil {
+		return nil, err
+	}
+	return cs.CloudComputeWithProvider(provider)
+}
+
+// Builds a ready to run CloudCompute using an existing compute provider
+func (cs *ComputeSpec) CloudComputeWithProvider(provider ComputeProvider) (*CloudCompute, error) {
+	plugins := make(map[string]Plugin)
+	for _, path := range cs.Plugins {
+		pf, err := LoadPluginFile(cs.path(path))
+		if err != nil {
+			return nil, err
+		}
+		plugins[pf.Plugin.Name] = pf.Plugin
+	}
+	events, err := cs.EventGenerator()
+	if err != nil {
+		return nil, err
+	}
+	return &CloudCompute{
+		ID:              uuid.New(),
+		Name:            cs.Name,
+		JobQueue:        cs.JobQueue,
+		Events:          events,
+		ComputeProvider: provider,
+		Plugins:         plugins,
+	}, nil
+}
+
+// resolves paths relative to the compute spec file
+func (cs *ComputeSpec) path(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(cs.baseDir, path)
+}
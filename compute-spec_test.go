@@ -0,0 +1,57 @@
+package cloudcompute
+
+import (
+	"testing"
+)
+
+func TestLoadComputeSpec(t *testing.T) {
+	spec, err := LoadComputeSpec("testdata/mmc-timing-compute.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.Name != "mmc-timing-study" || spec.JobQueue != "ffrd-large" || spec.Provider.Region != "us-east-1" {
+		t.Errorf("unexpected compute spec: %+v", spec)
+	}
+	timing := spec.Manifests[2]
+	if timing.Inputs.Environment.GetVal("MMC_FAIL_PLAN") != "model.p01.hdf" || timing.Inputs.Parameters["param1"] != "--cmd" {
+		t.Errorf("unexpected manifest inputs: %+v", timing.Inputs)
+	}
+
+	cc, err := spec.CloudComputeWithProvider(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cc.Plugins["mmc-arrival-time"]; !ok {
+		t.Error("expected the mmc-arrival-time plugin to be loaded")
+	}
+	count := 0
+	for cc.Events.HasNextEvent() {
+		event := cc.Events.NextEvent()
+		count++
+		manifests := event.Manifests
+		deps := manifests[2].Dependencies
+		if len(deps) != 2 || deps[0].JobId != manifests[0].ManifestID || deps[1].JobId != manifests[1].ManifestID {
+			t.Errorf("dependencies were not mapped to manifest ids: %v", deps)
+		}
+	}
+	if count != 3 {
+		t.Errorf("expected 3 events, got %d", count)
+	}
+}
+
+func TestValidateComputeSpec(t *testing.T) {
+	spec := ComputeSpec{
+		Name:      "test",
+		JobQueue:  "queue",
+		Generator: GeneratorSpec{Type: GeneratorArray, Start: 10, End: 1},
+		Manifests: []ManifestSpec{
+			{ComputeManifest: ComputeManifest{ManifestName: "a", PluginDefinition: "p"}, DependsOn: []string{"b"}},
+			{ComputeManifest: ComputeManifest{ManifestName: "a"}},
+		},
+	}
+	err := spec.Validate()
+	verrs, ok := err.(ValidationErrors)
+	if !ok || len(verrs) != 4 {
+		t.Errorf("expected 4 validation errors, got %v", err)
+	}
+}
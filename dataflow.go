@@ -0,0 +1,77 @@
+package cloudcompute
+
+import (
+	"fmt"
+	"log"
+
+	. "github.com/usace/cc-go-sdk"
+)
+
+type dataSourceKey struct {
+	name  string
+	store string
+}
+
+// Infers manifest dependencies from the data flow in the event.
+// A manifest with an input matching the name and store of another manifest's output
+// depends on that manifest.  Inferred dependencies are merged with the explicit dependencies.
+// Returns warnings for inputs with no producing manifest whose store is not declared in the
+// manifest stores, and for inputs produced by more than one manifest.
+func (e *Event) InferDependencies() ([]string, error) {
+	err := e.ResolveDependencies()
+	if err != nil {
+		return nil, err
+	}
+
+	producers := make(map[dataSourceKey][]string)
+	for _, m := range e.Manifests {
+		for _, ds := range m.Outputs {
+			key := dataSourceKey{ds.Name, ds.StoreName}
+			producers[key] = append(producers[key], m.ManifestID)
+		}
+	}
+
+	warnings := []string{}
+	for i := range e.Manifests {
+		m := &e.Manifests[i]
+		deps := make(map[string]bool)
+		for _, d := range m.Dependencies {
+			deps[d.JobId] = true
+		}
+		for _, ds := range m.Inputs.DataSources {
+			ids := []string{}
+			for _, id := range producers[dataSourceKey{ds.Name, ds.StoreName}] {
+				if id != m.ManifestID {
+					ids = append(ids, id)
+				}
+			}
+			switch {
+			case len(ids) == 0:
+				if !hasStore(m.Stores, ds.StoreName) {
+					warnings = append(warnings, fmt.Sprintf("manifest %s input %s has no producer and store %q is not declared", m.ManifestName, ds.Name, ds.StoreName))
+				}
+			case len(ids) > 1:
+				warnings = append(warnings, fmt.Sprintf("manifest %s input %s is produced by %d manifests", m.ManifestName, ds.Name, len(ids)))
+			}
+			for _, id := range ids {
+				if !deps[id] {
+					m.Dependencies = append(m.Dependencies, JobDependency{JobId: id})
+					deps[id] = true
+				}
+			}
+		}
+	}
+	for _, w := range warnings {
+		log.Printf("Event %d: %s\n", e.EventNumber, w)
+	}
+	return warnings, nil
+}
+
+func hasStore(stores []DataStore, name string) bool {
+	for _, s := range stores {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package cloudcompute
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/usace/cc-go-sdk"
+)
+
+func TestInferDependencies(t *testing.T) {
+	event := Event{
+		EventNumber: 1,
+		Manifests: []ComputeManifest{
+			{
+				ManifestName: "ras",
+				ManifestID:   "ras-id",
+				Stores:       []DataStore{{Name: "model-library"}},
+				Inputs: PluginInputs{DataSources: []DataSource{
+					{Name: "terrain", StoreName: "model-library"},
+				}},
+				Outputs: []DataSource{{Name: "depth-grid", StoreName: "results"}},
+			},
+			{
+				ManifestName: "consequences",
+				Inputs: PluginInputs{DataSources: []DataSource{
+					{Name: "depth-grid", StoreName: "results"},
+					{Name: "structures", StoreName: "inventory"},
+				}},
+				Outputs: []DataSource{{Name: "damages", StoreName: "results"}},
+			},
+			{
+				ManifestName: "timing",
+				Dependencies: []JobDependency{{ManifestName: "ras"}},
+				Inputs: PluginInputs{DataSources: []DataSource{
+					{Name: "depth-grid", StoreName: "results"},
+					{Name: "damages", StoreName: "results"},
+				}},
+			},
+		},
+	}
+	warnings, err := event.InferDependencies()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := event.Manifests
+	if len(m[0].Dependencies) != 0 {
+		t.Errorf("expected no dependencies for ras: %v", m[0].Dependencies)
+	}
+	if len(m[1].Dependencies) != 1 || m[1].Dependencies[0].JobId != "ras-id" {
+		t.Errorf("expected consequences to depend on ras: %v", m[1].Dependencies)
+	}
+	if len(m[2].Dependencies) != 2 || m[2].Dependencies[0].JobId != "ras-id" || m[2].Dependencies[1].JobId != m[1].ManifestID {
+		t.Errorf("expected timing to depend on ras once and on consequences: %v", m[2].Dependencies)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "structures") {
+		t.Errorf("expected a warning for the unproduced structures input: %v", warnings)
+	}
+}
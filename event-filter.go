@@ -0,0 +1,201 @@
+package cloudcompute
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type eventRange struct {
+	from int64
+	to   int64
+}
+
+// EventSet is a set of event numbers stored as ranges of consecutive numbers
+type EventSet struct {
+	ranges     []eventRange
+	normalized bool
+}
+
+// Parses an event set expression.  The expression is a comma separated
+// list of event numbers and inclusive ranges, for example "17, 204, 1000-1050"
+func ParseEventSet(expr string) (*EventSet, error) {
+	es := &EventSet{}
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		from, to := part, part
+		if i := strings.Index(part, "-"); i > 0 {
+			from, to = strings.TrimSpace(part[:i]), strings.TrimSpace(part[i+1:])
+		}
+		f, err := strconv.ParseInt(from, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid event set %q: invalid event number %q", expr, from)
+		}
+		t, err := strconv.ParseInt(to, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid event set %q: invalid event number %q", expr, to)
+		}
+		if t < f {
+			return nil, fmt.Errorf("Invalid event set %q: range %s ends before it starts", expr, part)
+		}
+		es.AddRange(f, t)
+	}
+	return es, nil
+}
+
+// Adds an event number to the set
+func (es *EventSet) Add(eventNumber int64) {
+	es.AddRange(eventNumber, eventNumber)
+}
+
+// Adds the inclusive range of event numbers to the set
+func (es *EventSet) AddRange(from int64, to int64) {
+	es.ranges = append(es.ranges, eventRange{from, to})
+	es.normalized = false
+}
+
+// Determines if the event number is in the set
+func (es *EventSet) Contains(eventNumber int64) bool {
+	es.normalize()
+	i := sort.Search(len(es.ranges), func(i int) bool {
+		return es.ranges[i].to >= eventNumber
+	})
+	return i < len(es.ranges) && es.ranges[i].from <= eventNumber
+}
+
+// Number of events in the set
+func (es *EventSet) Len() int64 {
+	es.normalize()
+	var n int64
+	for _, r := range es.ranges {
+		n += r.to - r.from + 1
+	}
+	return n
+}
+
+// Calls f for each event number in the set in ascending order
+func (es *EventSet) Each(f func(eventNumber int64)) {
+	es.normalize()
+	for _, r := range es.ranges {
+		for n := r.from; n <= r.to; n++ {
+			f(n)
+		}
+	}
+}
+
+// Formats the set as an event set expression
+func (es *EventSet) String() string {
+	es.normalize()
+	parts := make([]string, len(es.ranges))
+	for i, r := range es.ranges {
+		if r.from == r.to {
+			parts[i] = strconv.FormatInt(r.from, 10)
+		} else {
+			parts[i] = fmt.Sprintf("%d-%d", r.from, r.to)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sorts and merges overlapping and adjacent ranges
+func (es *EventSet) normalize() {
+	if es.normalized {
+		return
+	}
+	sort.Slice(es.ranges, func(i, j int) bool {
+		return es.ranges[i].from < es.ranges[j].from
+	})
+	merged := es.ranges[:0]
+	for _, r := range es.ranges {
+		last := len(merged) - 1
+		if last >= 0 && r.from <= merged[last].to+1 {
+			if r.to > merged[last].to {
+				merged[last].to = r.to
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	es.ranges = merged
+	es.normalized = true
+}
+
+// FilteredEventGenerator is an EventGenerator that only returns the events
+// of another generator that are accepted by a predicate.
+type FilteredEventGenerator struct {
+	events  EventGenerator
+	include func(event Event) bool
+	next    *Event
+}
+
+// Filters a generator using a predicate.  Events for which include returns false are skipped.
+func NewFilteredEventGenerator(events EventGenerator, include func(event Event) bool) *FilteredEventGenerator {
+	return &FilteredEventGenerator{
+		events:  events,
+		include: include,
+	}
+}
+
+// Filters a generator to the events with event numbers in the set
+func NewEventSetGenerator(events EventGenerator, set *EventSet) *FilteredEventGenerator {
+	return NewFilteredEventGenerator(events, func(event Event) bool {
+		return set.Contains(event.EventNumber)
+	})
+}
+
+func (feg *FilteredEventGenerator) HasNextEvent() bool {
+	if feg.next != nil {
+		return true
+	}
+	for feg.events.HasNextEvent() {
+		event := feg.events.NextEvent()
+		if feg.include(event) {
+			feg.next = &event
+			return true
+		}
+	}
+	return false
+}
+
+func (feg *FilteredEventGenerator) NextEvent() Event {
+	if feg.next == nil && !feg.HasNextEvent() {
+		return Event{}
+	}
+	event := *feg.next
+	feg.next = nil
+	return event
+}
+
+// Checkpoints the filtered generator.  Fails if the filtered generator is not checkpointable
+// or an event has been read ahead by HasNextEvent and not returned.
+func (feg *FilteredEventGenerator) Checkpoint() ([]byte, error) {
+	cg, ok := feg.events.(CheckpointableGenerator)
+	if !ok {
+		return nil, fmt.Errorf("Filtered event generator is not checkpointable")
+	}
+	if feg.next != nil {
+		return nil, fmt.Errorf("Unable to checkpoint a filtered generator with a pending event")
+	}
+	events, err := cg.Checkpoint()
+	if err != nil {
+		return nil, err
+	}
+	return encodeCheckpoint(generatorCheckpoint{Generator: "filter", Events: events})
+}
+
+func (feg *FilteredEventGenerator) Restore(checkpoint []byte) error {
+	cp, err := decodeCheckpoint("filter", checkpoint)
+	if err != nil {
+		return err
+	}
+	cg, ok := feg.events.(CheckpointableGenerator)
+	if !ok {
+		return fmt.Errorf("Filtered event generator is not checkpointable")
+	}
+	feg.next = nil
+	return cg.Restore(cp.Events)
+}
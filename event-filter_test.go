@@ -0,0 +1,120 @@
+package cloudcompute
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParseEventSet(t *testing.T) {
+	es, err := ParseEventSet("1000-1050, 17,204, 18, 1051-1060")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if es.String() != "17-18, 204, 1000-1060" || es.Len() != 64 {
+		t.Errorf("unexpected event set: %s (%d)", es, es.Len())
+	}
+	for _, en := range []int64{17, 18, 204, 1000, 1060} {
+		if !es.Contains(en) {
+			t.Errorf("expected the set to contain %d", en)
+		}
+	}
+	for _, en := range []int64{16, 19, 999, 1061} {
+		if es.Contains(en) {
+			t.Errorf("expected the set to not contain %d", en)
+		}
+	}
+	for _, expr := range []string{"17-", "a", "20-10"} {
+		if _, err := ParseEventSet(expr); err == nil {
+			t.Errorf("expected an error for %q", expr)
+		}
+	}
+}
+
+func TestEventSetGenerator(t *testing.T) {
+	events := make([]Event, 10)
+	for i := range events {
+		events[i] = Event{EventNumber: int64(i + 1)}
+	}
+	es, _ := ParseEventSet("2, 5-6, 10, 42")
+	feg := NewEventSetGenerator(NewEventList(events), es)
+	numbers := []int64{}
+	for feg.HasNextEvent() {
+		numbers = append(numbers, feg.NextEvent().EventNumber)
+	}
+	if len(numbers) != 4 || numbers[0] != 2 || numbers[1] != 5 || numbers[2] != 6 || numbers[3] != 10 {
+		t.Errorf("unexpected filtered events: %v", numbers)
+	}
+}
+
+func TestRerunSelection(t *testing.T) {
+	summaries := []JobSummary{
+		{JobName: "1a", Status: JobStatusSucceeded},
+		{JobName: "1b", Status: JobStatusSucceeded},
+		{JobName: "2a", Status: JobStatusSucceeded},
+		{JobName: "2b", Status: JobStatusFailed},
+		{JobName: "3a", Status: JobStatusSucceeded},
+		{JobName: "xx", Status: JobStatusFailed},
+	}
+	report := NewEventStatusReport(summaries, func(job JobSummary) (int64, bool) {
+		en := int64(job.JobName[0] - '0')
+		return en, en > 0 && en < 10
+	})
+	expected, _ := ParseEventSet("1-5")
+	rerun, err := report.Select(RerunSelection{
+		Failed:   true,
+		Expected: expected,
+		MissingOutputs: func(eventNumber int64) (bool, error) {
+			return eventNumber == 3, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rerun.String() != "2-5" {
+		t.Errorf("unexpected rerun set: %s", rerun)
+	}
+	_, err = report.Select(RerunSelection{MissingOutputs: func(int64) (bool, error) {
+		return false, errors.New("store unavailable")
+	}})
+	if err == nil {
+		t.Error("expected the output check error")
+	}
+}
+
+func TestEventStatusReportFromJobTags(t *testing.T) {
+	//the events share an event id like the array event generator
+	events := testEvents(3)
+	for i := range events {
+		events[i].ID = events[0].ID
+	}
+	provider := &testProvider{}
+	computeID := uuid.New()
+	cc := CloudCompute{ID: computeID, Events: NewEventList(events), ComputeProvider: provider}
+	if _, err := cc.Run(); err != nil {
+		t.Fatal(err)
+	}
+	for i, job := range provider.submitted() {
+		status := JobStatusSucceeded
+		if i == 1 {
+			status = JobStatusFailed
+		}
+		provider.summaries = append(provider.summaries, JobSummary{
+			JobId:   *job.SubmittedJob.JobId,
+			JobName: job.JobName,
+			Status:  status,
+			Tags:    job.Tags,
+		})
+	}
+
+	//a new process only knows the compute id
+	restarted := CloudCompute{ID: computeID, ComputeProvider: provider}
+	report, err := restarted.EventStatusReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Jobs) != 3 || report.Failed().String() != "2" || report.Succeeded().String() != "1, 3" {
+		t.Errorf("unexpected report: %d events, failed %s, succeeded %s", len(report.Jobs), report.Failed(), report.Succeeded())
+	}
+}
@@ -0,0 +1,250 @@
+package cloudcompute
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// Environment variables and job tags for the hierarchical event index
+const (
+	CcRealization string = "CC_REALIZATION"
+	CcBlock       string = "CC_BLOCK"
+	CcBlockEvent  string = "CC_BLOCK_EVENT"
+
+	TagRealization string = "realization"
+	TagBlock       string = "block"
+	TagBlockEvent  string = "block_event"
+)
+
+// Levels of the hierarchical event index used for status rollups
+const (
+	IndexLevelRealization string = "REALIZATION"
+	IndexLevelBlock       string = "BLOCK"
+	IndexLevelEvent       string = "EVENT"
+)
+
+// EventIndex is the position of an event in a stochastic workflow.
+// Realizations are divided into lifecycle blocks of events.
+// Event is the position of the event in its block starting at 1.
+type EventIndex struct {
+	Realization int64 `json:"realization" yaml:"realization"`
+	Block       int64 `json:"block" yaml:"block"`
+	Event       int64 `json:"event" yaml:"event"`
+}
+
+// Determines if the index is in a realization and block.  A zero realization or block matches any value.
+func (ei EventIndex) In(realization int64, block int64) bool {
+	return (realization == 0 || ei.Realization == realization) && (block == 0 || ei.Block == block)
+}
+
+// Environment variables injected into each job of an indexed event
+func (ei EventIndex) Environment() []KeyValuePair {
+	return []KeyValuePair{
+		{CcRealization, strconv.FormatInt(ei.Realization, 10)},
+		{CcBlock, strconv.FormatInt(ei.Block, 10)},
+		{CcBlockEvent, strconv.FormatInt(ei.Event, 10)},
+	}
+}
+
+// Tags added to each job of an indexed event.  The index is kept out of the job name
+// to stay within the compute provider name length limits.
+func (ei EventIndex) Tags() map[string]string {
+	return map[string]string{
+		TagRealization: strconv.FormatInt(ei.Realization, 10),
+		TagBlock:       strconv.FormatInt(ei.Block, 10),
+		TagBlockEvent:  strconv.FormatInt(ei.Event, 10),
+	}
+}
+
+// reads the event index tags of a job
+func summaryEventIndex(job JobSummary) (EventIndex, bool) {
+	ei := EventIndex{}
+	for tag, value := range map[string]*int64{
+		TagRealization: &ei.Realization,
+		TagBlock:       &ei.Block,
+		TagBlockEvent:  &ei.Event,
+	} {
+		v, err := strconv.ParseInt(job.Tags[tag], 10, 64)
+		if err != nil {
+			return ei, false
+		}
+		*value = v
+	}
+	return ei, true
+}
+
+// truncates the index to a rollup level
+func (ei EventIndex) level(level string) (EventIndex, error) {
+	switch level {
+	case IndexLevelRealization:
+		return EventIndex{Realization: ei.Realization}, nil
+	case IndexLevelBlock:
+		return EventIndex{Realization: ei.Realization, Block: ei.Block}, nil
+	case IndexLevelEvent:
+		return ei, nil
+	}
+	return ei, fmt.Errorf("Invalid index level %q", level)
+}
+
+// EventBlock is a lifecycle block of a realization.
+// Start and End are the inclusive range of event numbers in the block.
+// The JSON format matches the blocks files written by the stochastic storm and lifecycle tools.
+type EventBlock struct {
+	Realization int64 `json:"realization_index" yaml:"realization"`
+	Block       int64 `json:"block_index" yaml:"block"`
+	Start       int64 `json:"block_event_start" yaml:"start"`
+	End         int64 `json:"block_event_end" yaml:"end"`
+}
+
+// Loads event blocks from a JSON file
+func LoadEventBlocks(path string) ([]EventBlock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	blocks := []EventBlock{}
+	err = json.Unmarshal(data, &blocks)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid blocks file %s: %s", path, err)
+	}
+	return blocks, nil
+}
+
+// BlockEventGenerator is an EventGenerator that generates the events of a set of
+// realization blocks from an event template.  Each event is assigned a unique identifier
+// and its hierarchical index.  Blocks with no events (End before Start) are skipped.
+type BlockEventGenerator struct {
+	event    Event
+	blocks   []EventBlock
+	block    int
+	position int64
+}
+
+func NewBlockEventGenerator(event Event, blocks []EventBlock) (*BlockEventGenerator, error) {
+	err := prepareEvent(&event)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockEventGenerator{
+		event:    event,
+		blocks:   blocks,
+		position: -1,
+	}, nil
+}
+
+func (beg *BlockEventGenerator) HasNextEvent() bool {
+	for beg.block < len(beg.blocks) {
+		b := beg.blocks[beg.block]
+		if beg.position < 0 {
+			beg.position = b.Start
+		}
+		if beg.position <= b.End {
+			return true
+		}
+		beg.block++
+		beg.position = -1
+	}
+	return false
+}
+
+func (beg *BlockEventGenerator) NextEvent() Event {
+	if !beg.HasNextEvent() {
+		return Event{}
+	}
+	b := beg.blocks[beg.block]
+	event := beg.event
+	event.ID = uuid.New()
+	event.EventNumber = beg.position
+	event.Index = &EventIndex{
+		Realization: b.Realization,
+		Block:       b.Block,
+		Event:       beg.position - b.Start + 1,
+	}
+	beg.position++
+	return event
+}
+
+// Filters a generator to the indexed events in a realization and block.
+// A zero realization or block matches any value.
+func NewEventIndexGenerator(events EventGenerator, realization int64, block int64) *FilteredEventGenerator {
+	return NewFilteredEventGenerator(events, func(event Event) bool {
+		return event.Index != nil && event.Index.In(realization, block)
+	})
+}
+
+// StatusRollup is the number of events in each state for a level of the event index
+type StatusRollup struct {
+	Index     EventIndex
+	Events    int
+	Succeeded int
+	Failed    int
+	Active    int
+}
+
+// Rolls up the event statuses to a level of the event index.
+// An event has failed if any job failed, succeeded if every job succeeded, and is active otherwise.
+// Events without an index are not included.  Rollups are ordered by index.
+func (r EventStatusReport) Rollup(level string) ([]StatusRollup, error) {
+	rollups := make(map[EventIndex]*StatusRollup)
+	for en, jobs := range r.Jobs {
+		ei, ok := r.Indexes[en]
+		if !ok {
+			continue
+		}
+		key, err := ei.level(level)
+		if err != nil {
+			return nil, err
+		}
+		rollup, ok := rollups[key]
+		if !ok {
+			rollup = &StatusRollup{Index: key}
+			rollups[key] = rollup
+		}
+		rollup.Events++
+		switch eventStatus(jobs) {
+		case JobStatusSucceeded:
+			rollup.Succeeded++
+		case JobStatusFailed:
+			rollup.Failed++
+		default:
+			rollup.Active++
+		}
+	}
+	result := make([]StatusRollup, 0, len(rollups))
+	for _, rollup := range rollups {
+		result = append(result, *rollup)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i].Index, result[j].Index
+		if a.Realization != b.Realization {
+			return a.Realization < b.Realization
+		}
+		if a.Block != b.Block {
+			return a.Block < b.Block
+		}
+		return a.Event < b.Event
+	})
+	return result, nil
+}
+
+func (beg *BlockEventGenerator) Checkpoint() ([]byte, error) {
+	return encodeCheckpoint(generatorCheckpoint{Generator: "blocks", Block: beg.block, Position: beg.position})
+}
+
+func (beg *BlockEventGenerator) Restore(checkpoint []byte) error {
+	cp, err := decodeCheckpoint("blocks", checkpoint)
+	if err != nil {
+		return err
+	}
+	if cp.Block < 0 || cp.Block > len(beg.blocks) {
+		return fmt.Errorf("Invalid checkpoint: block %d is outside of the %d blocks", cp.Block, len(beg.blocks))
+	}
+	beg.block = cp.Block
+	beg.position = cp.Position
+	return nil
+}
@@ -0,0 +1,129 @@
+package cloudcompute
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestBlockEventGenerator(t *testing.T) {
+	blocks := []EventBlock{
+		{Realization: 1, Block: 1, Start: 1, End: 3},
+		{Realization: 1, Block: 2, Start: 4, End: 3},
+		{Realization: 1, Block: 3, Start: 4, End: 5},
+		{Realization: 2, Block: 1, Start: 6, End: 7},
+	}
+	beg, err := NewBlockEventGenerator(sweepEvent(), blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []Event{}
+	for beg.HasNextEvent() {
+		events = append(events, beg.NextEvent())
+	}
+	if len(events) != 7 {
+		t.Fatalf("expected 7 events, got %d", len(events))
+	}
+	e := events[4]
+	if e.EventNumber != 5 || *e.Index != (EventIndex{Realization: 1, Block: 3, Event: 2}) {
+		t.Errorf("unexpected event %d index %+v", e.EventNumber, e.Index)
+	}
+
+	beg, _ = NewBlockEventGenerator(sweepEvent(), blocks)
+	filtered := NewEventIndexGenerator(beg, 1, 3)
+	count := 0
+	for filtered.HasNextEvent() {
+		if filtered.NextEvent().Index.Block != 3 {
+			t.Error("expected only events in block 3")
+		}
+		count++
+	}
+	if count != 2 {
+		t.Errorf("expected 2 events in realization 1 block 3, got %d", count)
+	}
+}
+
+func TestStatusRollup(t *testing.T) {
+	report := EventStatusReport{
+		Jobs: map[int64][]JobSummary{
+			1: {{Status: JobStatusSucceeded}},
+			2: {{Status: JobStatusSucceeded}, {Status: JobStatusFailed}},
+			3: {{Status: "RUNNING"}},
+			6: {{Status: JobStatusSucceeded}},
+		},
+		Indexes: map[int64]EventIndex{
+			1: {1, 1, 1},
+			2: {1, 1, 2},
+			3: {1, 2, 1},
+			6: {2, 1, 1},
+		},
+	}
+	rollups, err := report.Rollup(IndexLevelRealization)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rollups) != 2 || rollups[0] != (StatusRollup{EventIndex{Realization: 1}, 3, 1, 1, 1}) || rollups[1].Succeeded != 1 {
+		t.Errorf("unexpected realization rollups: %+v", rollups)
+	}
+	rollups, _ = report.Rollup(IndexLevelBlock)
+	if len(rollups) != 3 || rollups[1].Index != (EventIndex{Realization: 1, Block: 2}) || rollups[1].Active != 1 {
+		t.Errorf("unexpected block rollups: %+v", rollups)
+	}
+	if _, err := report.Rollup("SEASON"); err == nil {
+		t.Error("expected an error for an invalid level")
+	}
+}
+
+func TestRenderEventIndex(t *testing.T) {
+	event := Event{
+		Index:     &EventIndex{Realization: 3, Block: 12, Event: 4},
+		Manifests: []ComputeManifest{{Command: []string{"r{{.Index.Realization}}/b{{.Index.Block}}/e{{.Index.Event}}"}}},
+	}
+	err := event.Render("c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Manifests[0].Command[0] != "r3/b12/e4" {
+		t.Errorf("unexpected rendered command: %v", event.Manifests[0].Command)
+	}
+}
+
+func TestStatusRollupFromJobTags(t *testing.T) {
+	blocks := []EventBlock{
+		{Realization: 1, Block: 1, Start: 1, End: 2},
+		{Realization: 1, Block: 2, Start: 3, End: 4},
+	}
+	beg, err := NewBlockEventGenerator(Event{Manifests: []ComputeManifest{{ManifestName: "ras", PluginDefinition: "ras"}}}, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &testProvider{}
+	computeID := uuid.New()
+	cc := CloudCompute{ID: computeID, Events: beg, ComputeProvider: provider}
+	if _, err := cc.Run(); err != nil {
+		t.Fatal(err)
+	}
+	for i, job := range provider.submitted() {
+		status := JobStatusSucceeded
+		if i == 3 {
+			status = JobStatusFailed
+		}
+		provider.summaries = append(provider.summaries, JobSummary{JobId: *job.SubmittedJob.JobId, JobName: job.JobName, Status: status, Tags: job.Tags})
+	}
+
+	restarted := CloudCompute{ID: computeID, ComputeProvider: provider}
+	report, err := restarted.EventStatusReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rollups, err := report.Rollup(IndexLevelBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rollups) != 2 || rollups[0] != (StatusRollup{EventIndex{Realization: 1, Block: 1}, 2, 2, 0, 0}) || rollups[1].Failed != 1 {
+		t.Errorf("unexpected rollups %+v", rollups)
+	}
+	if report.Indexes[4] != (EventIndex{Realization: 1, Block: 2, Event: 2}) {
+		t.Errorf("unexpected index for event 4: %+v", report.Indexes[4])
+	}
+}
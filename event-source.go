@@ -0,0 +1,142 @@
+package cloudcompute
+
+import (
+	"context"
+	"io"
+)
+
+// EventSource is an error aware event generator.
+// Next returns io.EOF when there are no more events.  Any other error stops the compute.
+// CloudCompute.Run uses the Next method of event generators that implement EventSource.
+type EventSource interface {
+	Next(ctx context.Context) (Event, error)
+}
+
+// generatorSource adapts an EventGenerator to an EventSource.
+// Generators that report errors with an Err method (e.g. the file backed generators)
+// have their errors returned when they stop generating events.
+type generatorSource struct {
+	events EventGenerator
+}
+
+// Adapts an EventGenerator to an EventSource
+func NewEventSource(events EventGenerator) EventSource {
+	if source, ok := events.(EventSource); ok {
+		return source
+	}
+	return &generatorSource{events}
+}
+
+func (gs *generatorSource) Next(ctx context.Context) (Event, error) {
+	if err := ctx.Err(); err != nil {
+		return Event{}, err
+	}
+	if !gs.events.HasNextEvent() {
+		if eg, ok := gs.events.(interface{ Err() error }); ok && eg.Err() != nil {
+			return Event{}, eg.Err()
+		}
+		return Event{}, io.EOF
+	}
+	return gs.events.NextEvent(), nil
+}
+
+// SourceEventGenerator adapts an EventSource to an EventGenerator.
+// HasNextEvent blocks until the source returns the next event or an error.
+// The error that stopped the generator is available from Err.
+type SourceEventGenerator struct {
+	source  EventSource
+	pending *Event
+	err     error
+}
+
+func NewSourceEventGenerator(source EventSource) *SourceEventGenerator {
+	return &SourceEventGenerator{source: source}
+}
+
+func (seg *SourceEventGenerator) HasNextEvent() bool {
+	if seg.pending != nil {
+		return true
+	}
+	if seg.err != nil {
+		return false
+	}
+	event, err := seg.source.Next(context.Background())
+	if err != nil {
+		seg.err = err
+		return false
+	}
+	seg.pending = &event
+	return true
+}
+
+func (seg *SourceEventGenerator) NextEvent() Event {
+	if !seg.HasNextEvent() {
+		return Event{}
+	}
+	event := *seg.pending
+	seg.pending = nil
+	return event
+}
+
+// Returns the next event from the source.  Implements EventSource so Run can cancel
+// a blocked source through the context.
+func (seg *SourceEventGenerator) Next(ctx context.Context) (Event, error) {
+	if seg.pending != nil {
+		event := *seg.pending
+		seg.pending = nil
+		return event, nil
+	}
+	if seg.err != nil {
+		return Event{}, seg.err
+	}
+	event, err := seg.source.Next(ctx)
+	if err != nil && err != ctx.Err() {
+		seg.err = err
+	}
+	return event, err
+}
+
+// Returns the error that stopped the generator.  A source that finished normally returns nil.
+func (seg *SourceEventGenerator) Err() error {
+	if seg.err == io.EOF {
+		return nil
+	}
+	return seg.err
+}
+
+// ChannelEventSource is an EventSource fed by an upstream process.
+// The source finishes when the events channel is closed.  An error received on the
+// optional errors channel stops the source.
+type ChannelEventSource struct {
+	events <-chan Event
+	errs   <-chan error
+}
+
+// Creates an EventSource reading from channels.  errs can be nil.
+// To run a compute from a channel use NewSourceEventGenerator(NewChannelEventSource(events, errs)).
+func NewChannelEventSource(events <-chan Event, errs <-chan error) *ChannelEventSource {
+	return &ChannelEventSource{events, errs}
+}
+
+func (ces *ChannelEventSource) Next(ctx context.Context) (Event, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return Event{}, ctx.Err()
+		case err, ok := <-ces.errs:
+			if !ok {
+				//stop selecting on a closed errors channel
+				ces.errs = nil
+				continue
+			}
+			if err != nil {
+				return Event{}, err
+			}
+		case event, ok := <-ces.events:
+			if !ok {
+				return Event{}, io.EOF
+			}
+			return event, nil
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package cloudcompute
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestEventSourceAdapter(t *testing.T) {
+	path := writeTestFile(t, t.TempDir(), "events.csv", "event_number\n1\nx\n")
+	ceg, err := NewCsvEventGenerator(FileEventGeneratorInput{Path: path, Event: sweepEvent()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := NewEventSource(ceg)
+	event, err := source.Next(context.Background())
+	if err != nil || event.EventNumber != 1 {
+		t.Fatalf("unexpected first event: %d %v", event.EventNumber, err)
+	}
+	_, err = source.Next(context.Background())
+	if err == nil || err == io.EOF {
+		t.Errorf("expected the generator error, got %v", err)
+	}
+}
+
+func TestEventListHasNextEventIsIdempotent(t *testing.T) {
+	el := NewEventList([]Event{{EventNumber: 1}, {EventNumber: 2}})
+	el.HasNextEvent()
+	el.HasNextEvent()
+	if el.NextEvent().EventNumber != 1 || el.NextEvent().EventNumber != 2 || el.HasNextEvent() {
+		t.Error("expected HasNextEvent to not advance the list")
+	}
+}
+
+func TestRunFromChannel(t *testing.T) {
+	events := make(chan Event)
+	errs := make(chan error, 1)
+	provider := &testProvider{}
+	cc := CloudCompute{
+		ID:              uuid.New(),
+		JobQueue:        "queue",
+		Events:          NewSourceEventGenerator(NewChannelEventSource(events, errs)),
+		ComputeProvider: provider,
+	}
+	go func() {
+		for i := int64(1); i <= 3; i++ {
+			events <- Event{ID: uuid.New(), EventNumber: i, Manifests: []ComputeManifest{{ManifestName: "ras", PluginDefinition: "ras"}}}
+		}
+		errs <- errors.New("upstream failed")
+	}()
+	_, err := cc.Run()
+	if err == nil || !strings.Contains(err.Error(), "upstream failed") || len(provider.submitted()) != 3 {
+		t.Fatalf("expected the run to stop after 3 events with the upstream error: %d %v", len(provider.submitted()), err)
+	}
+}
+
+func TestRunContextCancel(t *testing.T) {
+	provider := &testProvider{}
+	cc := CloudCompute{
+		ID:              uuid.New(),
+		Events:          NewSourceEventGenerator(NewChannelEventSource(make(chan Event), nil)),
+		ComputeProvider: provider,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := cc.RunContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the run to stop when the context is cancelled: %v", err)
+	}
+}
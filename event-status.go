@@ -0,0 +1,161 @@
+package cloudcompute
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Compute provider job status values used to summarize events
+const (
+	JobStatusSucceeded string = "SUCCEEDED"
+	JobStatusFailed    string = "FAILED"
+)
+
+// Job tag with the event number of the job
+const TagEventNumber string = "event_number"
+
+// EventStatusReport is the status of the jobs submitted for a compute grouped by event number
+type EventStatusReport struct {
+	Jobs map[int64][]JobSummary
+
+	//Optional. hierarchical index of each event number used for status rollups
+	Indexes map[int64]EventIndex
+}
+
+// Groups job summaries by event number.  eventNumber maps a job to its event number and
+// returns false for jobs that are not part of the report.
+func NewEventStatusReport(summaries []JobSummary, eventNumber func(job JobSummary) (int64, bool)) EventStatusReport {
+	report := EventStatusReport{Jobs: make(map[int64][]JobSummary)}
+	for _, s := range summaries {
+		if en, ok := eventNumber(s); ok {
+			report.Jobs[en] = append(report.Jobs[en], s)
+		}
+	}
+	return report
+}
+
+// Events with at least one failed job
+func (r EventStatusReport) Failed() *EventSet {
+	return r.withStatus(JobStatusFailed)
+}
+
+// Events where every job succeeded
+func (r EventStatusReport) Succeeded() *EventSet {
+	return r.withStatus(JobStatusSucceeded)
+}
+
+func (r EventStatusReport) withStatus(status string) *EventSet {
+	events := &EventSet{}
+	for en, jobs := range r.Jobs {
+		if eventStatus(jobs) == status {
+			events.Add(en)
+		}
+	}
+	return events
+}
+
+// The status of an event is FAILED if any job failed, SUCCEEDED if every job succeeded,
+// and empty while jobs are still active
+func eventStatus(jobs []JobSummary) string {
+	succeeded := len(jobs) > 0
+	for _, j := range jobs {
+		if j.Status == JobStatusFailed {
+			return JobStatusFailed
+		}
+		if j.Status != JobStatusSucceeded {
+			succeeded = false
+		}
+	}
+	if succeeded {
+		return JobStatusSucceeded
+	}
+	return ""
+}
+
+// Expected events with no submitted jobs
+func (r EventStatusReport) NotSubmitted(expected *EventSet) *EventSet {
+	missing := &EventSet{}
+	expected.Each(func(en int64) {
+		if len(r.Jobs[en]) == 0 {
+			missing.Add(en)
+		}
+	})
+	return missing
+}
+
+// Criteria for selecting events to rerun from an EventStatusReport
+type RerunSelection struct {
+	//select events with a failed job
+	Failed bool
+
+	//Optional. events that should have run.  expected events without any submitted jobs are selected
+	Expected *EventSet
+
+	//Optional. reports if any of the outputs of a succeeded event are missing
+	MissingOutputs func(eventNumber int64) (bool, error)
+}
+
+// Builds the set of events to rerun.  The set can be used with NewEventSetGenerator
+// to rerun the events from the original event generator.
+func (r EventStatusReport) Select(selection RerunSelection) (*EventSet, error) {
+	rerun := &EventSet{}
+	if selection.Failed {
+		r.Failed().Each(rerun.Add)
+	}
+	if selection.Expected != nil {
+		r.NotSubmitted(selection.Expected).Each(rerun.Add)
+	}
+	if selection.MissingOutputs != nil {
+		var err error
+		r.Succeeded().Each(func(en int64) {
+			if err != nil {
+				return
+			}
+			var missing bool
+			missing, err = selection.MissingOutputs(en)
+			if missing {
+				rerun.Add(en)
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Unable to check the outputs of the compute: %w", err)
+		}
+	}
+	return rerun, nil
+}
+
+// Builds the event status report for the jobs submitted by the compute.
+// Events and their hierarchical index are identified by the tags of the submitted jobs, so the
+// report can be built by any process with the compute ID.  Jobs without an event number tag are skipped.
+func (cc *CloudCompute) EventStatusReport() (EventStatusReport, error) {
+	summaries := []JobSummary{}
+	err := cc.Status(JobsSummaryQuery{
+		QueryLevel:  SUMMARY_COMPUTE,
+		QueryValue:  JobNameParts{Compute: cc.ID.String()},
+		IncludeTags: true,
+		JobSummaryFunction: func(s []JobSummary) {
+			summaries = append(summaries, s...)
+		},
+	})
+	if err != nil {
+		return EventStatusReport{}, err
+	}
+	report := NewEventStatusReport(summaries, summaryEventNumber)
+	report.Indexes = make(map[int64]EventIndex)
+	for _, s := range summaries {
+		en, ok := summaryEventNumber(s)
+		if !ok {
+			continue
+		}
+		if ei, ok := summaryEventIndex(s); ok {
+			report.Indexes[en] = ei
+		}
+	}
+	return report, nil
+}
+
+// reads the event number tag of a job
+func summaryEventNumber(job JobSummary) (int64, bool) {
+	en, err := strconv.ParseInt(job.Tags[TagEventNumber], 10, 64)
+	return en, err == nil
+}
@@ -1,9 +1,9 @@
 package cloudcompute
 
 import (
-	"errors"
 	"fmt"
-	"log"
+
+	"github.com/google/uuid"
 )
 
 // EventGenerators provide an iterator type interface to work with sets of events for a Compute.
@@ -19,33 +19,24 @@ type ArrayEventGenerator struct {
 	position int64
 }
 
+// Creates a generator for the range of event numbers start to end inclusive.
+// Fails if the event manifests do not form a valid DAG.
 func NewArrayEventGenerator(event Event, start int64, end int64) (*ArrayEventGenerator, error) {
-	manifestCount := len(event.Manifests)
-
-	//order the set of manifests
-	if manifestCount > 1 {
-		orderedIds, err := event.TopoSort()
-		if err != nil {
-			log.Printf("Unable to order event %s: %s\n", event.ID, err)
-		}
-		orderedManifests := make([]ComputeManifest, len(event.Manifests))
-		for i, oid := range orderedIds {
-			orderedManifests[i], err = getManifest(event.Manifests, oid)
-			if err != nil {
-				log.Printf("Unable to order event %s: %s\n", event.ID, err)
-			}
-		}
-		event.Manifests = orderedManifests
+	err := prepareEvent(&event)
+	if err != nil {
+		return nil, err
 	}
 
-	for i := 0; i < manifestCount; i++ {
-		err := event.Manifests[i].WritePayload()
-		if err != nil {
-			return nil, fmt.Errorf("Failed to write payload for manifest %s: %s\n", event.Manifests[i].ManifestID, err)
+	//the payload of each manifest is written once when the first event is submitted.
+	//templated manifests have their payloads written for each event
+	for i := range event.Manifests {
+		if !event.Manifests[i].IsTemplated() {
+			event.Manifests[i].sharePayload()
 		}
 	}
 	return &ArrayEventGenerator{
 		event:    event,
+		start:    start,
 		position: start,
 		end:      end,
 	}, nil
@@ -55,29 +46,12 @@ func (aeg *ArrayEventGenerator) HasNextEvent() bool {
 	return aeg.position <= aeg.end
 }
 
-// @TODO this is quick and dirty...needs two changes
-//  1. move sorting to a new function shared by the event generators
-//  2. only sort once.  Can add a sorted flag to the ArrayEventGenerator...or something like that
+// Returns the next event.  The manifests were ordered when the generator was created
+// and are shared by every event.
 func (aeg *ArrayEventGenerator) NextEvent() Event {
 	event := aeg.event
 	event.EventNumber = aeg.position
 	aeg.position++
-	if len(event.Manifests) > 1 {
-		orderedIds, err := event.TopoSort()
-		if err != nil {
-			log.Printf("Unable to order event %s: %s\n", event.ID, err)
-			return event
-		}
-		orderedManifests := make([]ComputeManifest, len(event.Manifests))
-		for i, oid := range orderedIds {
-			orderedManifests[i], err = getManifest(event.Manifests, oid)
-			if err != nil {
-				log.Printf("Unable to order event %s: %s\n", event.ID, err)
-				return event
-			}
-		}
-		event.Manifests = orderedManifests
-	}
 	return event
 }
 
@@ -99,34 +73,79 @@ func NewEventList(events []Event) *EventList {
 
 // Determines if all of the events have been enumerated
 func (el *EventList) HasNextEvent() bool {
-	el.currentEvent++
-	return el.currentEvent < len(el.events)
+	return el.currentEvent+1 < len(el.events)
 }
 
-//@TODO: Could optimize the sorting an instead of returning a list of ordered IDs, return a sorted list of manifests.....
-
-// Retrieves the next event.  Attempts to perform a topological sort on the manifest slice before returning.
-// If sort fails it will log the issue and return the unsorted manifest slice
+// Retrieves the next event.  The manifests are ordered when the event is run.
 func (el *EventList) NextEvent() Event {
+	if !el.HasNextEvent() {
+		return Event{}
+	}
+	el.currentEvent++
 	event := el.events[el.currentEvent]
 
 	return event
 }
 
-func getManifest(manifests []ComputeManifest, id string) (ComputeManifest, error) {
-	for _, m := range manifests {
-		if m.ManifestID == id {
-			return m, nil
-		}
+// Prepares an event template for a generator.  Dependencies are resolved and the manifests
+// are ordered once so that the events generated from the template are submitted in order.
+func prepareEvent(event *Event) error {
+	err := event.ResolveDependencies()
+	if err != nil {
+		return err
 	}
-	return ComputeManifest{}, errors.New("Unable to find Manifest in list")
+	return event.OrderManifests()
 }
 
-// StochasticEvents is an EventGenerator that generates sets of stochastic events
-// based on a manifest tempate and start and end indices.
-// This type is not currently implemented
+// StochasticEvents is an EventGenerator that generates a range of stochastic events
+// from an event template.  Unlike the ArrayEventGenerator, each event is assigned
+// a unique event identifier so events can be queried and cancelled individually.
 type StochasticEvents struct {
-	eventStartIndex  int
-	eventEndIndex    int
-	manifestTemplate ComputeManifest
+	*ArrayEventGenerator
+}
+
+func NewStochasticEvents(event Event, start int64, end int64) (*StochasticEvents, error) {
+	aeg, err := NewArrayEventGenerator(event, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return &StochasticEvents{aeg}, nil
+}
+
+func (se *StochasticEvents) NextEvent() Event {
+	event := se.ArrayEventGenerator.NextEvent()
+	event.ID = uuid.New()
+	return event
+}
+
+func (aeg *ArrayEventGenerator) Checkpoint() ([]byte, error) {
+	return encodeCheckpoint(generatorCheckpoint{Generator: "array", Position: aeg.position})
+}
+
+func (aeg *ArrayEventGenerator) Restore(checkpoint []byte) error {
+	cp, err := decodeCheckpoint("array", checkpoint)
+	if err != nil {
+		return err
+	}
+	if cp.Position < aeg.start || cp.Position > aeg.end+1 {
+		return fmt.Errorf("Invalid checkpoint: position %d is outside of the range %d to %d", cp.Position, aeg.start, aeg.end)
+	}
+	aeg.position = cp.Position
+	return nil
+}
+
+func (el *EventList) Checkpoint() ([]byte, error) {
+	return encodeCheckpoint(generatorCheckpoint{Generator: "list", Position: int64(el.currentEvent)})
+}
+
+func (el *EventList) Restore(checkpoint []byte) error {
+	cp, err := decodeCheckpoint("list", checkpoint)
+	if err != nil {
+		return err
+	}
+	if cp.Position < -1 || cp.Position >= int64(len(el.events)) {
+		return fmt.Errorf("Invalid checkpoint: position %d is outside of the list of %d events", cp.Position, len(el.events))
+	}
+	el.currentEvent = int(cp.Position)
+	return nil
 }
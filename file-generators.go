@@ -0,0 +1,393 @@
+package cloudcompute
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+const EventNumberField string = "event_number"
+
+// Inputs for the file backed event generators.
+// Offset is the position to resume from as reported by the generator Offset method.
+type FileEventGeneratorInput struct {
+	Path   string
+	Event  Event
+	Offset int64
+}
+
+// lineEventReader streams events from a file with one event per line.
+// Blank lines are skipped.  The reader reads one line ahead of the events returned
+// so that errors are reported before an event is requested.
+type lineEventReader struct {
+	path      string
+	file      *os.File
+	reader    *bufio.Reader
+	next      int64 //offset of the next unread line
+	consumed  int64 //offset following the last event returned by NextEvent
+	pending   *Event
+	pendingAt int64
+	parse     func(line string) (Event, error)
+	err       error
+}
+
+func openLineEventReader(path string, offset int64, header bool) (*lineEventReader, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	ler := lineEventReader{
+		path:   path,
+		file:   file,
+		reader: bufio.NewReader(file),
+	}
+	headerLine := ""
+	if header {
+		headerLine, err = ler.reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			file.Close()
+			return nil, "", err
+		}
+		ler.next = int64(len(headerLine))
+		headerLine = strings.TrimRight(headerLine, "\r\n")
+	}
+	if offset > ler.next {
+		_, err = file.Seek(offset, io.SeekStart)
+		if err != nil {
+			file.Close()
+			return nil, "", err
+		}
+		ler.reader.Reset(file)
+		ler.next = offset
+	}
+	ler.consumed = ler.next
+	return &ler, headerLine, nil
+}
+
+func (ler *lineEventReader) HasNextEvent() bool {
+	if ler.pending != nil {
+		return true
+	}
+	if ler.err != nil || ler.file == nil {
+		return false
+	}
+	for {
+		line, err := ler.reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			ler.fail(err)
+			return false
+		}
+		at := ler.next
+		ler.next += int64(len(line))
+		trimmed := strings.TrimRight(line, "\r\n")
+		if strings.TrimSpace(trimmed) != "" {
+			event, perr := ler.parse(trimmed)
+			if perr != nil {
+				ler.fail(fmt.Errorf("Invalid event at offset %d of %s: %s", at, ler.path, perr))
+				return false
+			}
+			ler.pending = &event
+			ler.pendingAt = ler.next
+			return true
+		}
+		if err == io.EOF {
+			ler.Close()
+			return false
+		}
+	}
+}
+
+func (ler *lineEventReader) NextEvent() Event {
+	if ler.pending == nil && !ler.HasNextEvent() {
+		return Event{}
+	}
+	event := *ler.pending
+	ler.pending = nil
+	ler.consumed = ler.pendingAt
+	return event
+}
+
+// Byte offset following the last event returned.  Passing the offset to the
+// generator constructor resumes the generator with the next event.
+func (ler *lineEventReader) Offset() int64 {
+	return ler.consumed
+}
+
+// Returns the error that stopped the generator, if any
+func (ler *lineEventReader) Err() error {
+	return ler.err
+}
+
+// Closes the underlying file.  The file is closed automatically when all of the events
+// have been read or an error occurs.
+func (ler *lineEventReader) Close() error {
+	if ler.file == nil {
+		return nil
+	}
+	err := ler.file.Close()
+	ler.file = nil
+	return err
+}
+
+func (ler *lineEventReader) fail(err error) {
+	ler.err = err
+	ler.Close()
+}
+
+// CsvEventGenerator streams events from a CSV file with one row per event.
+// The header row must include an event_number column.  The other columns are
+// event variables added to the environment and payload attributes of the base event manifests.
+// Quoted values may not span lines.
+type CsvEventGenerator struct {
+	*lineEventReader
+}
+
+func NewCsvEventGenerator(input FileEventGeneratorInput) (*CsvEventGenerator, error) {
+	err := prepareEvent(&input.Event)
+	if err != nil {
+		return nil, err
+	}
+	ler, header, err := openLineEventReader(input.Path, input.Offset, true)
+	if err != nil {
+		return nil, err
+	}
+	names, err := parseCsvLine(header)
+	if err != nil {
+		ler.Close()
+		return nil, fmt.Errorf("Invalid header in %s: %s", input.Path, err)
+	}
+	eventNumberCol := -1
+	for i, name := range names {
+		if name == EventNumberField {
+			eventNumberCol = i
+		}
+	}
+	if eventNumberCol < 0 {
+		ler.Close()
+		return nil, fmt.Errorf("Invalid header in %s: missing %s column", input.Path, EventNumberField)
+	}
+	varNames := append(append([]string{}, names[:eventNumberCol]...), names[eventNumberCol+1:]...)
+	ler.parse = func(line string) (Event, error) {
+		vals, err := parseCsvLine(line)
+		if err != nil {
+			return Event{}, err
+		}
+		if len(vals) != len(names) {
+			return Event{}, fmt.Errorf("expected %d values, got %d", len(names), len(vals))
+		}
+		eventNumber, err := strconv.ParseInt(strings.TrimSpace(vals[eventNumberCol]), 10, 64)
+		if err != nil {
+			return Event{}, fmt.Errorf("invalid event number %q", vals[eventNumberCol])
+		}
+		values := make([]interface{}, 0, len(varNames))
+		for i, v := range vals {
+			if i != eventNumberCol {
+				values = append(values, v)
+			}
+		}
+		return newFileEvent(input.Event, eventNumber, varNames, values), nil
+	}
+	return &CsvEventGenerator{ler}, nil
+}
+
+func parseCsvLine(line string) ([]string, error) {
+	return csv.NewReader(strings.NewReader(line)).Read()
+}
+
+// JsonLinesEventGenerator streams events from a JSON Lines file with one object per event.
+// Each object must include an event_number.  The other fields are event variables
+// added to the environment and payload attributes of the base event manifests in name order.
+type JsonLinesEventGenerator struct {
+	*lineEventReader
+}
+
+func NewJsonLinesEventGenerator(input FileEventGeneratorInput) (*JsonLinesEventGenerator, error) {
+	err := prepareEvent(&input.Event)
+	if err != nil {
+		return nil, err
+	}
+	ler, _, err := openLineEventReader(input.Path, input.Offset, false)
+	if err != nil {
+		return nil, err
+	}
+	ler.parse = func(line string) (Event, error) {
+		fields := make(map[string]interface{})
+		err := json.Unmarshal([]byte(line), &fields)
+		if err != nil {
+			return Event{}, err
+		}
+		en, ok := fields[EventNumberField].(float64)
+		if !ok || en != float64(int64(en)) {
+			return Event{}, fmt.Errorf("missing or invalid %s", EventNumberField)
+		}
+		delete(fields, EventNumberField)
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		values := make([]interface{}, len(names))
+		for i, name := range names {
+			values[i] = fields[name]
+		}
+		return newFileEvent(input.Event, int64(en), names, values), nil
+	}
+	return &JsonLinesEventGenerator{ler}, nil
+}
+
+func newFileEvent(base Event, eventNumber int64, names []string, values []interface{}) Event {
+	event := base.withVariables(names, values)
+	event.ID = uuid.New()
+	event.EventNumber = eventNumber
+	return event
+}
+
+// DirectoryEventGenerator reads complete events from a directory of JSON or YAML files
+// (.json, .yaml, .yml) in file name order.  Events without an ID are assigned one and
+// manifest dependencies are resolved and ordered when the event is read.
+type DirectoryEventGenerator struct {
+	files    []string
+	position int64
+	pending  *Event
+	err      error
+}
+
+// Creates a generator for the event files in a directory.
+// Offset is the number of files to skip as reported by the Offset method.
+func NewDirectoryEventGenerator(dir string, offset int64) (*DirectoryEventGenerator, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	for _, e := range entries {
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".json", ".yaml", ".yml":
+			if !e.IsDir() {
+				files = append(files, filepath.Join(dir, e.Name()))
+			}
+		}
+	}
+	sort.Strings(files)
+	if offset < 0 || offset > int64(len(files)) {
+		return nil, fmt.Errorf("Invalid offset %d for %d event files in %s", offset, len(files), dir)
+	}
+	return &DirectoryEventGenerator{files: files, position: offset}, nil
+}
+
+func (deg *DirectoryEventGenerator) HasNextEvent() bool {
+	if deg.pending != nil {
+		return true
+	}
+	if deg.err != nil || deg.position >= int64(len(deg.files)) {
+		return false
+	}
+	event, err := loadEventFile(deg.files[deg.position])
+	if err != nil {
+		deg.err = err
+		return false
+	}
+	deg.pending = &event
+	return true
+}
+
+func (deg *DirectoryEventGenerator) NextEvent() Event {
+	if deg.pending == nil && !deg.HasNextEvent() {
+		return Event{}
+	}
+	event := *deg.pending
+	deg.pending = nil
+	deg.position++
+	return event
+}
+
+// Number of event files returned.  Passing the offset to the generator
+// constructor resumes the generator with the next event file.
+func (deg *DirectoryEventGenerator) Offset() int64 {
+	return deg.position
+}
+
+// Returns the error that stopped the generator, if any
+func (deg *DirectoryEventGenerator) Err() error {
+	return deg.err
+}
+
+func loadEventFile(path string) (Event, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Event{}, err
+	}
+	event := Event{}
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(data, &event)
+	} else {
+		err = yaml.Unmarshal(data, &event)
+	}
+	if err != nil {
+		return Event{}, fmt.Errorf("Invalid event file %s: %s", path, err)
+	}
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	err = prepareEvent(&event)
+	if err != nil {
+		return Event{}, fmt.Errorf("Invalid event file %s: %s", path, err)
+	}
+	return event, nil
+}
+
+func (ler *lineEventReader) Checkpoint() ([]byte, error) {
+	return encodeCheckpoint(generatorCheckpoint{Generator: "lines", Position: ler.consumed})
+}
+
+// Restores the reader to the byte offset saved in the checkpoint, reopening the file if it was closed
+func (ler *lineEventReader) Restore(checkpoint []byte) error {
+	cp, err := decodeCheckpoint("lines", checkpoint)
+	if err != nil {
+		return err
+	}
+	if ler.file == nil {
+		ler.file, err = os.Open(ler.path)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = ler.file.Seek(cp.Position, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	ler.reader.Reset(ler.file)
+	ler.next = cp.Position
+	ler.consumed = cp.Position
+	ler.pending = nil
+	ler.err = nil
+	return nil
+}
+
+func (deg *DirectoryEventGenerator) Checkpoint() ([]byte, error) {
+	return encodeCheckpoint(generatorCheckpoint{Generator: "directory", Position: deg.position})
+}
+
+func (deg *DirectoryEventGenerator) Restore(checkpoint []byte) error {
+	cp, err := decodeCheckpoint("directory", checkpoint)
+	if err != nil {
+		return err
+	}
+	if cp.Position < 0 || cp.Position > int64(len(deg.files)) {
+		return fmt.Errorf("Invalid checkpoint: position %d is outside of the %d event files", cp.Position, len(deg.files))
+	}
+	deg.position = cp.Position
+	deg.pending = nil
+	deg.err = nil
+	return nil
+}
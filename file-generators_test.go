@@ -0,0 +1,86 @@
+package cloudcompute
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, dir string, name string, contents string) string {
+	path := filepath.Join(dir, name)
+	err := os.WriteFile(path, []byte(contents), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCsvEventGeneratorResume(t *testing.T) {
+	path := writeTestFile(t, t.TempDir(), "events.csv", "roughness,event_number,scenario\n0.02,17,base\n\n0.04,204,\"future, wet\"\n0.05,1000,base\n")
+	ceg, err := NewCsvEventGenerator(FileEventGeneratorInput{Path: path, Event: sweepEvent()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := ceg.NextEvent()
+	if first.EventNumber != 17 || first.Vars["roughness"] != "0.02" || first.Manifests[0].Inputs.PayloadAttributes["scenario"] != "base" {
+		t.Errorf("unexpected first event: %d %v", first.EventNumber, first.Vars)
+	}
+	//read ahead without consuming the event
+	if !ceg.HasNextEvent() {
+		t.Fatal("expected more events")
+	}
+	offset := ceg.Offset()
+	ceg.Close()
+
+	resumed, err := NewCsvEventGenerator(FileEventGeneratorInput{Path: path, Event: sweepEvent(), Offset: offset})
+	if err != nil {
+		t.Fatal(err)
+	}
+	numbers := []int64{}
+	for resumed.HasNextEvent() {
+		event := resumed.NextEvent()
+		numbers = append(numbers, event.EventNumber)
+		if event.EventNumber == 204 && event.Vars["scenario"] != "future, wet" {
+			t.Errorf("unexpected quoted value: %v", event.Vars)
+		}
+	}
+	if resumed.Err() != nil {
+		t.Fatal(resumed.Err())
+	}
+	if len(numbers) != 2 || numbers[0] != 204 || numbers[1] != 1000 {
+		t.Errorf("expected to resume at event 204: %v", numbers)
+	}
+}
+
+func TestJsonLinesEventGenerator(t *testing.T) {
+	path := writeTestFile(t, t.TempDir(), "events.jsonl", "{\"event_number\": 3, \"roughness\": 0.03, \"scenario\": \"base\"}\n{\"event_number\": \"x\"}\n")
+	jeg, err := NewJsonLinesEventGenerator(FileEventGeneratorInput{Path: path, Event: sweepEvent()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := jeg.NextEvent()
+	if event.EventNumber != 3 || event.Manifests[0].Inputs.PayloadAttributes["roughness"] != 0.03 || event.Vars["scenario"] != "base" {
+		t.Errorf("unexpected event: %d %v", event.EventNumber, event.Manifests[0].Inputs.PayloadAttributes)
+	}
+	if jeg.HasNextEvent() || jeg.Err() == nil {
+		t.Error("expected an error for the invalid event number")
+	}
+}
+
+func TestDirectoryEventGenerator(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "001.json", `{"event_number": 1, "manifests": [{"manifest_name": "ras"}]}`)
+	writeTestFile(t, dir, "002.yaml", "event_number: 2\nmanifests:\n  - manifest_name: ras\n  - manifest_name: timing\n    dependencies:\n      - manifest_name: ras\n")
+	writeTestFile(t, dir, "notes.txt", "ignored")
+	deg, err := NewDirectoryEventGenerator(dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := deg.NextEvent()
+	if event.EventNumber != 2 || event.Manifests[1].Dependencies[0].JobId != event.Manifests[0].ManifestID {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if deg.HasNextEvent() || deg.Err() != nil || deg.Offset() != 2 {
+		t.Errorf("expected the generator to finish at offset 2: %d %v", deg.Offset(), deg.Err())
+	}
+}
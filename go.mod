@@ -9,6 +9,7 @@ require (
 	github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs v1.35.7
 	github.com/google/uuid v1.6.0
 	github.com/usace/cc-go-sdk v0.0.0-20240611184425-06f941ff4742
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
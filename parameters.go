@@ -0,0 +1,86 @@
+package cloudcompute
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+var parameterRefRegex = regexp.MustCompile(`Ref::([A-Za-z0-9_\-]+)`)
+
+// ParameterRefReport compares the Ref:: placeholders in a command with the supplied parameters
+type ParameterRefReport struct {
+	//placeholders in the command without a parameter value
+	Missing []string
+
+	//parameters that are not referenced by any placeholder
+	Unused []string
+}
+
+// Returns an error listing the missing and unused parameters, or nil if there are none
+func (r ParameterRefReport) Err() error {
+	errs := ValidationErrors{}
+	for _, m := range r.Missing {
+		errs = append(errs, fmt.Sprintf("missing parameter for placeholder Ref::%s", m))
+	}
+	for _, u := range r.Unused {
+		errs = append(errs, fmt.Sprintf("parameter %s is not referenced by the command", u))
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Returns the sorted set of Ref:: placeholder names in a command
+func ParameterRefs(command []string) []string {
+	seen := make(map[string]bool)
+	refs := []string{}
+	for _, arg := range command {
+		for _, match := range parameterRefRegex.FindAllStringSubmatch(arg, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				refs = append(refs, match[1])
+			}
+		}
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// Compares the Ref:: placeholders in a command against a set of parameter maps.
+// A placeholder is supplied if its name is a key in any of the maps.
+func CheckParameterRefs(command []string, params ...map[string]string) ParameterRefReport {
+	supplied := make(map[string]bool)
+	for _, p := range params {
+		for k := range p {
+			supplied[k] = true
+		}
+	}
+	report := ParameterRefReport{}
+	referenced := make(map[string]bool)
+	for _, ref := range ParameterRefs(command) {
+		referenced[ref] = true
+		if !supplied[ref] {
+			report.Missing = append(report.Missing, ref)
+		}
+	}
+	for k := range supplied {
+		if !referenced[k] {
+			report.Unused = append(report.Unused, k)
+		}
+	}
+	sort.Strings(report.Unused)
+	return report
+}
+
+// Checks the command that will be run for a manifest against the parameters submitted
+// with the job: the schema defaults and plugin default parameters merged with the manifest
+// parameters.  The manifest command is used if it overrides the plugin command.
+func (p *Plugin) CheckParameters(manifest *ComputeManifest) ParameterRefReport {
+	command := p.Command
+	if len(manifest.Command) > 0 {
+		command = manifest.Command
+	}
+	return CheckParameterRefs(command, p.jobParameters(manifest))
+}
@@ -0,0 +1,85 @@
+package cloudcompute
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCheckParameterRefs(t *testing.T) {
+	command := []string{"/app/mmc-timing", "Ref::param1", "--out=Ref::output", "Ref::param1"}
+	report := CheckParameterRefs(command, map[string]string{"param1": "--cmd", "pram2": "x"}, map[string]string{"outptu": "/data"})
+	if !reflect.DeepEqual(report.Missing, []string{"output"}) {
+		t.Errorf("unexpected missing parameters: %v", report.Missing)
+	}
+	if !reflect.DeepEqual(report.Unused, []string{"outptu", "pram2"}) {
+		t.Errorf("unexpected unused parameters: %v", report.Unused)
+	}
+	if report.Err() == nil {
+		t.Error("expected an error")
+	}
+	if CheckParameterRefs(command, map[string]string{"param1": "a", "output": "b"}).Err() != nil {
+		t.Error("expected no errors")
+	}
+}
+
+func TestValidateEventParameters(t *testing.T) {
+	cc := CloudCompute{
+		Plugins: map[string]Plugin{
+			"mmc-timing": {
+				Name:       "mmc-timing",
+				Command:    []string{"/app/mmc-timing", "Ref::param1"},
+				Parameters: map[string]string{"param1": "--cmd", "debug": "false"},
+			},
+		},
+	}
+	event := Event{
+		Manifests: []ComputeManifest{
+			{ManifestName: "timing", PluginDefinition: "mmc-timing:2"},
+		},
+	}
+	if unused, err := cc.validateEvent(&event); err != nil || len(unused) != 0 {
+		t.Errorf("unused plugin defaults should be allowed: %v %s", unused, err)
+	}
+	event.Manifests[0].Inputs.Parameters = map[string]string{"parm1": "--cmd2"}
+	unused, err := cc.validateEvent(&event)
+	if err != nil {
+		t.Errorf("unused manifest parameters should not fail the event: %s", err)
+	}
+	if len(unused) != 1 || unused[0].ManifestName != "timing" || unused[0].Parameter != "parm1" {
+		t.Errorf("expected the unused manifest parameter to be returned: %v", unused)
+	}
+	event.Manifests[0].Inputs.Parameters = nil
+	event.Manifests[0].Command = []string{"/app/mmc-timing", "Ref::mode"}
+	if _, err := cc.validateEvent(&event); err == nil {
+		t.Error("expected a missing parameter error")
+	}
+}
+
+func TestRunReportUnusedParameters(t *testing.T) {
+	events := testEvents(3)
+	for i := range events {
+		events[i].Manifests[0].Inputs.Parameters = map[string]string{"parm1": "--cmd2"}
+	}
+	provider := &testProvider{}
+	cc := CloudCompute{
+		ID:              uuid.New(),
+		Events:          NewEventList(events),
+		ComputeProvider: provider,
+		Plugins: map[string]Plugin{
+			"ras": {Name: "ras", Command: []string{"/app/ras", "Ref::param1"}, Parameters: map[string]string{"param1": "--cmd"}},
+		},
+	}
+	report, err := cc.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []UnusedParameter{{EventNumber: 1, ManifestName: "ras", Parameter: "parm1"}}
+	if !reflect.DeepEqual(report.UnusedParameters, expected) {
+		t.Errorf("expected the unused parameter reported once, got %v", report.UnusedParameters)
+	}
+	if job := provider.submitted()[0]; job.Parameters["param1"] != "--cmd" {
+		t.Errorf("expected the plugin default parameter to be submitted: %v", job.Parameters)
+	}
+}
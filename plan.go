@@ -0,0 +1,115 @@
+package cloudcompute
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/google/uuid"
+)
+
+// Plan builds every job the compute would submit without submitting jobs or writing payloads.
+//
+// Events are generated, ordered, rendered, and validated the same as Run, and jobs are
+// constructed with the same environment and tags.  Manifests that write a payload when they
+// are submitted are assigned a new payload ID that is not written.  Jobs depend on the
+// JobName of their upstream jobs in place of the compute provider job IDs.
+//
+// Plan reads the event generator to the end.  Generators that can be checkpointed are
+// restored to their position before the plan, so the compute can be run with the same
+// generator.  Other generators must be recreated to run the compute.
+// Checkpoint stores, backpressure, and the observer are not used.
+func (cc *CloudCompute) Plan() ([]Job, error) {
+	jobs := []Job{}
+	err := cc.plan(func(job Job) error {
+		jobs = append(jobs, job)
+		return nil
+	})
+	return jobs, err
+}
+
+// Writes the planned jobs as a JSON array.  Jobs are written as they are planned
+// so large computes are not held in memory.
+func (cc *CloudCompute) WritePlan(w io.Writer) error {
+	_, err := io.WriteString(w, "[")
+	if err != nil {
+		return err
+	}
+	count := 0
+	err = cc.plan(func(job Job) error {
+		data, err := json.MarshalIndent(job, "  ", "  ")
+		if err != nil {
+			return err
+		}
+		sep := ",\n  "
+		if count == 0 {
+			sep = "\n  "
+		}
+		count++
+		_, err = io.WriteString(w, sep)
+		if err == nil {
+			_, err = w.Write(data)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	end := "]\n"
+	if count > 0 {
+		end = "\n]\n"
+	}
+	_, err = io.WriteString(w, end)
+	return err
+}
+
+// builds the jobs for each event and passes them to f in submission order
+func (cc *CloudCompute) plan(f func(job Job) error) (err error) {
+	//a generator that is unable to checkpoint (e.g. a filter of a list reader) is read to the end
+	if cg, ok := cc.Events.(CheckpointableGenerator); ok {
+		if checkpoint, cperr := cg.Checkpoint(); cperr == nil {
+			defer func() {
+				rerr := cg.Restore(checkpoint)
+				if err == nil {
+					err = rerr
+				}
+			}()
+		}
+	}
+
+	//planned ids of payloads shared by the events of a generator
+	shared := make(map[*sharedPayload]uuid.UUID)
+	source := NewEventSource(cc.Events)
+	for {
+		event, err := source.Next(context.Background())
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		_, err = cc.buildEvent(&event)
+		if err != nil {
+			return err
+		}
+		jobNames := make(map[string]string, len(event.Manifests))
+		for _, manifest := range event.Manifests {
+			if manifest.hasPayload() && manifest.payloadID == uuid.Nil {
+				id := uuid.New()
+				if manifest.shared != nil {
+					if planned, ok := shared[manifest.shared]; ok {
+						id = planned
+					}
+					shared[manifest.shared] = id
+				}
+				manifest.setPayload(id)
+			}
+			job := cc.newJob(event, manifest, cc.mapDependencies(&manifest, jobNames))
+			jobNames[manifest.ManifestID] = job.JobName
+			err = f(job)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
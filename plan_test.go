@@ -0,0 +1,142 @@
+package cloudcompute
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	. "github.com/usace/cc-go-sdk"
+
+	"github.com/google/uuid"
+)
+
+func planEvents() []Event {
+	events := testEvents(2)
+	for i := range events {
+		events[i].Manifests = append(events[i].Manifests, ComputeManifest{
+			ManifestName:     "hms",
+			ManifestID:       "hms-id",
+			PluginDefinition: "hms",
+			Dependencies:     []JobDependency{{JobId: "ras-id"}},
+			Inputs: PluginInputs{
+				Environment:       KeyValuePairs{{"MODEL", "hms"}},
+				PayloadAttributes: PayloadAttributes{"scenario": "base"},
+			},
+		})
+	}
+	return events
+}
+
+func TestPlan(t *testing.T) {
+	provider := &testProvider{}
+	events := planEvents()
+	cc := CloudCompute{
+		ID:              uuid.New(),
+		JobQueue:        "queue",
+		Events:          NewEventList(events),
+		ComputeProvider: provider,
+	}
+	jobs, err := cc.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.submitted()) != 0 {
+		t.Fatal("expected plan not to submit jobs")
+	}
+	if len(jobs) != 4 {
+		t.Fatalf("expected 4 planned jobs, got %d", len(jobs))
+	}
+	ras, hms := jobs[2], jobs[3]
+	env := KeyValuePairs(hms.ContainerOverrides.Environment)
+	if env.GetVal(CcEventID) != events[1].ID.String() || env.GetVal(CcEventNumber) != "2" || env.GetVal(CcManifestId) != "hms-id" || env.GetVal("MODEL") != "hms" {
+		t.Errorf("unexpected planned environment %v", env)
+	}
+	payload := env.GetVal(CcPayloadId)
+	if payload == uuid.Nil.String() || hms.Tags["payload"] != payload {
+		t.Errorf("expected a planned payload id, got %s and tag %s", payload, hms.Tags["payload"])
+	}
+	if KeyValuePairs(ras.ContainerOverrides.Environment).GetVal(CcPayloadId) != uuid.Nil.String() {
+		t.Error("expected no payload for a manifest without payload inputs")
+	}
+	if len(hms.DependsOn) != 1 || hms.DependsOn[0].JobId != ras.JobName || hms.JobQueue != "queue" {
+		t.Errorf("expected hms to depend on the planned ras job: %+v", hms.DependsOn)
+	}
+	if events[1].Manifests[1].Tags != nil || len(events[1].Manifests[1].Inputs.Environment) != 1 {
+		t.Error("expected plan not to modify the generated manifests")
+	}
+}
+
+func TestWritePlan(t *testing.T) {
+	cc := CloudCompute{ID: uuid.New(), Events: NewEventList(planEvents())}
+	buf := bytes.Buffer{}
+	err := cc.WritePlan(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := []Job{}
+	err = json.Unmarshal(buf.Bytes(), &jobs)
+	if err != nil {
+		t.Fatalf("invalid plan json: %s\n%s", err, buf.String())
+	}
+	if len(jobs) != 4 || jobs[3].DependsOn[0].JobId != jobs[2].JobName {
+		t.Errorf("unexpected jobs in the written plan: %d", len(jobs))
+	}
+
+	empty := CloudCompute{ID: uuid.New(), Events: NewEventList(nil)}
+	buf.Reset()
+	if err := empty.WritePlan(&buf); err != nil || buf.String() != "[]\n" {
+		t.Errorf("expected an empty plan array, got %q %v", buf.String(), err)
+	}
+}
+
+// countingStore records payload writes in place of the cc store
+type countingStore struct {
+	CcStore
+	writes *int
+}
+
+func (cs countingStore) SetPayload(p Payload) error {
+	*cs.writes++
+	return nil
+}
+
+func TestPlanArrayEventsWritesNoPayloads(t *testing.T) {
+	writes := 0
+	newStore := newCcStore
+	newCcStore = func(args ...string) (CcStore, error) {
+		return countingStore{writes: &writes}, nil
+	}
+	defer func() { newCcStore = newStore }()
+
+	aeg, err := NewArrayEventGenerator(planEvents()[0], 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &testProvider{}
+	cc := CloudCompute{ID: uuid.New(), Events: aeg, ComputeProvider: provider}
+	jobs, err := cc.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if writes != 0 {
+		t.Fatalf("expected plan not to write payloads, got %d writes", writes)
+	}
+	if len(jobs) != 6 || jobs[1].Tags["payload"] == "" || jobs[1].Tags["payload"] != jobs[5].Tags["payload"] {
+		t.Errorf("expected the planned events to share a payload id")
+	}
+
+	//the planned generator is restored so it can be run
+	if _, err := cc.Run(); err != nil {
+		t.Fatal(err)
+	}
+	submitted := provider.submitted()
+	if len(submitted) != 6 {
+		t.Fatalf("expected the planned events to be submitted, got %d jobs", len(submitted))
+	}
+	if writes != 2 {
+		t.Errorf("expected a shared payload written once for each manifest, got %d writes", writes)
+	}
+	if submitted[1].Tags["payload"] != submitted[5].Tags["payload"] || submitted[0].Tags["payload"] == submitted[1].Tags["payload"] {
+		t.Errorf("expected each manifest payload to be shared by the events")
+	}
+}
@@ -0,0 +1,247 @@
+package cloudcompute
+
+import (
+	"fmt"
+	"os"
+
+	. "github.com/usace/cc-go-sdk"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RegisteredSource is a data source registered for use by plugins.
+// Credentials is the resource name of the secret holding the source credentials.
+// Any source used by a plugin manifest gets its credentials injected into the
+// plugin environment using EnvPrefix.
+type RegisteredSource struct {
+	Name        string `json:"name" yaml:"name"`
+	Store       string `json:"store" yaml:"store"`           //FILE, DB
+	StoreType   string `json:"store_type" yaml:"store_type"` //S3, POSTGRES
+	Root        string `json:"root" yaml:"root"`
+	EnvPrefix   string `json:"env_prefix" yaml:"env_prefix"`
+	Credentials string `json:"credentials" yaml:"credentials"`
+}
+
+// Converts a registered source into a payload data store
+func (rs RegisteredSource) DataStore() DataStore {
+	params := PayloadAttributes{}
+	if rs.Root != "" {
+		params["root"] = rs.Root
+	}
+	return DataStore{
+		Name:       rs.Name,
+		StoreType:  StoreType(rs.StoreType),
+		DsProfile:  rs.EnvPrefix,
+		Parameters: params,
+	}
+}
+
+// Secret keys injected for each store type.
+// Store types not listed have the entire secret injected as {PREFIX}_CREDENTIALS
+var sourceCredentialKeys = map[string][]string{
+	"S3": {AwsAccessKeyId, AwsSecretAccessKey, AwsDefaultRegion, AwsS3Bucket},
+}
+
+// Credentials formatted for the compute provider secret injection.
+// For example: MODEL_LIBRARY_AWS_ACCESS_KEY_ID=arn:aws:secretsmanager:...:secret:mysecret:AWS_ACCESS_KEY_ID::
+func (rs RegisteredSource) CredentialsEnvironment() []KeyValuePair {
+	if rs.Credentials == "" {
+		return nil
+	}
+	keys, ok := sourceCredentialKeys[rs.StoreType]
+	if !ok {
+		return []KeyValuePair{{envName(rs.EnvPrefix, "CREDENTIALS"), rs.Credentials}}
+	}
+	creds := make([]KeyValuePair, len(keys))
+	for i, key := range keys {
+		creds[i] = KeyValuePair{envName(rs.EnvPrefix, key), fmt.Sprintf("%s:%s::", rs.Credentials, key)}
+	}
+	return creds
+}
+
+func envName(prefix string, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "_" + key
+}
+
+// SourceRegistry is the set of registered sources keyed by name
+type SourceRegistry map[string]RegisteredSource
+
+// Adds the sources from another registry.
+// A source registered in both with different definitions is an error.
+func (sr SourceRegistry) Merge(other SourceRegistry) error {
+	for name, source := range other {
+		if existing, ok := sr[name]; ok && existing != source {
+			return fmt.Errorf("Conflicting definitions for source %s", name)
+		}
+		sr[name] = source
+	}
+	return nil
+}
+
+// PluginFile is a plugin definition loaded from a YAML file
+type PluginFile struct {
+	Plugin   Plugin
+	Sources  SourceRegistry
+	Manifest PluginManifest
+}
+
+// Loads a plugin definition file
+func LoadPluginFile(path string) (PluginFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return PluginFile{}, err
+	}
+	pf, err := ParsePluginFile(data)
+	if err != nil {
+		return PluginFile{}, fmt.Errorf("Invalid plugin file %s: %s", path, err)
+	}
+	return pf, nil
+}
+
+// Parses a plugin definition.  The plugin credentials are the credentials of every
+// source referenced by the plugin manifest.
+func ParsePluginFile(data []byte) (PluginFile, error) {
+	var pfy pluginFileYaml
+	err := yaml.Unmarshal(data, &pfy)
+	if err != nil {
+		return PluginFile{}, err
+	}
+
+	sources := SourceRegistry{}
+	for _, s := range pfy.Sources {
+		if s.Source.Name == "" {
+			return PluginFile{}, fmt.Errorf("Invalid source: missing name")
+		}
+		if _, ok := sources[s.Source.Name]; ok {
+			return PluginFile{}, fmt.Errorf("Invalid source %s: duplicate source name", s.Source.Name)
+		}
+		sources[s.Source.Name] = s.Source
+	}
+
+	py := pfy.Plugin
+	plugin := Plugin{
+		Name:               py.Name,
+		ImageAndTag:        py.ImageAndTag,
+		Description:        py.Description,
+		Command:            py.Command,
+		ComputeEnvironment: py.ComputeEnvironment,
+		DefaultEnvironment: py.Environment,
+		Credentials:        py.Credentials,
+		Parameters:         py.Parameters,
+		RetryAttemts:       py.RetryAttempts,
+		ExecutionTimeout:   py.ExecutionTimeout,
+		PlatformCapability: py.PlatformCapability,
+		AssignPublicIp:     py.AssignPublicIp,
+		PlatformVersion:    py.PlatformVersion,
+	}
+	schema := py.Manifest.Schema()
+	plugin.Schema = &schema
+	for _, v := range py.Volumes {
+		plugin.Volumes = append(plugin.Volumes, PluginComputeVolumes(v))
+	}
+
+	used := make(map[string]bool)
+	for _, ds := range py.Manifest.Inputs.DataSources {
+		source, ok := sources[ds.Source]
+		if !ok {
+			return PluginFile{}, fmt.Errorf("Data source %s uses unregistered source %s", ds.Name, ds.Source)
+		}
+		if !used[ds.Source] {
+			plugin.Credentials = append(plugin.Credentials, source.CredentialsEnvironment()...)
+			used[ds.Source] = true
+		}
+	}
+
+	return PluginFile{
+		Plugin:   plugin,
+		Sources:  sources,
+		Manifest: py.Manifest,
+	}, nil
+}
+
+// Validates the plugin and its sources
+func (pf *PluginFile) Validate() error {
+	for name, source := range pf.Sources {
+		if source.Store == "" || source.StoreType == "" {
+			return fmt.Errorf("Invalid source %s: missing store or store type", name)
+		}
+	}
+	return pf.Plugin.Validate()
+}
+
+// Validates and registers the plugin with the compute provider
+func (pf *PluginFile) Register(provider ComputeProvider) (PluginRegistrationOutput, error) {
+	err := pf.Validate()
+	if err != nil {
+		return PluginRegistrationOutput{}, err
+	}
+	return provider.RegisterPlugin(&pf.Plugin)
+}
+
+/////////////////////////////
+//////// YAML FORMAT ////////
+
+type pluginFileYaml struct {
+	Sources []struct {
+		Source RegisteredSource `yaml:"source"`
+	} `yaml:"sources"`
+	Plugin pluginYaml `yaml:"plugin"`
+}
+
+type pluginYaml struct {
+	Name               string                   `yaml:"name"`
+	Description        string                   `yaml:"description"`
+	ImageAndTag        string                   `yaml:"image_and_tag"`
+	Command            []string                 `yaml:"command"`
+	ComputeEnvironment PluginComputeEnvironment `yaml:"compute_environment"`
+	Environment        environmentYaml          `yaml:"environment"`
+	Volumes            []volumeYaml             `yaml:"volumes"`
+	Credentials        environmentYaml          `yaml:"credentials"`
+	Parameters         map[string]string        `yaml:"parameters"`
+	RetryAttempts      int32                    `yaml:"retry_attempts"`
+	ExecutionTimeout   *int32                   `yaml:"execution_timeout"`
+	PlatformCapability PlatformCapability       `yaml:"platform_capability"`
+	AssignPublicIp     bool                     `yaml:"assign_public_ip"`
+	PlatformVersion    string                   `yaml:"platform_version"`
+	Manifest           PluginManifest           `yaml:"manifest"`
+}
+
+// Environment variables can be written as a mapping of names to values
+// or as a sequence of name/value pairs.  Mapping order is preserved.
+type environmentYaml []KeyValuePair
+
+func (e *environmentYaml) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		kvps := make([]KeyValuePair, 0, len(node.Content)/2)
+		for i := 0; i < len(node.Content); i += 2 {
+			kvps = append(kvps, KeyValuePair{node.Content[i].Value, node.Content[i+1].Value})
+		}
+		*e = kvps
+		return nil
+	case yaml.SequenceNode:
+		var kvps []KeyValuePair
+		err := node.Decode(&kvps)
+		*e = kvps
+		return err
+	}
+	return fmt.Errorf("line %d: environment must be a mapping or a sequence", node.Line)
+}
+
+// Volumes can be written as a full volume definition or only a name.
+// A volume given only by name is a local scratch volume.
+type volumeYaml PluginComputeVolumes
+
+func (v *volumeYaml) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*v = volumeYaml{Name: node.Value, Type: VolumeTypeLocal}
+		return nil
+	}
+	var pcv PluginComputeVolumes
+	err := node.Decode(&pcv)
+	*v = volumeYaml(pcv)
+	return err
+}
@@ -0,0 +1,81 @@
+package cloudcompute
+
+import (
+	"testing"
+)
+
+func TestLoadPluginFile(t *testing.T) {
+	pf, err := LoadPluginFile("testdata/mmc-timing-plugin.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pf.Sources) != 2 || pf.Sources["NSI_POSTGRES_STORE"].StoreType != "POSTGRES" {
+		t.Errorf("expected two registered sources, got %v", pf.Sources)
+	}
+	plugin := pf.Plugin
+	if plugin.Name != "mmc-arrival-time" || len(plugin.Command) != 2 || plugin.Command[1] != "Ref::param1" {
+		t.Errorf("unexpected plugin: %+v", plugin)
+	}
+	if plugin.ComputeEnvironment.VCPU != "1" || plugin.ComputeEnvironment.Memory != "2048" {
+		t.Errorf("unexpected compute environment: %+v", plugin.ComputeEnvironment)
+	}
+	env := KeyValuePairs(plugin.DefaultEnvironment)
+	if len(env) != 3 || env[0].Name != "RASLIBCMD" || env.GetVal("MMC_DELTA") != "2.0" {
+		t.Errorf("unexpected environment: %v", env)
+	}
+	if len(plugin.Volumes) != 1 || plugin.Volumes[0].Name != "LOCAL" || plugin.Volumes[0].Type != VolumeTypeLocal {
+		t.Errorf("unexpected volumes: %v", plugin.Volumes)
+	}
+	if plugin.Parameters["param1"] != "--cmd" {
+		t.Errorf("unexpected parameters: %v", plugin.Parameters)
+	}
+	//both manifest data sources use the S3 model library so only its credentials are injected
+	creds := KeyValuePairs(plugin.Credentials)
+	if len(creds) != 4 || creds.GetVal("MODEL_LIBRARY_AWS_ACCESS_KEY_ID") != "arn:aws:secretsmanager:us-east-1:038611608639:secret:dev/s3/mmc-storage-6-dI6kN5:AWS_ACCESS_KEY_ID::" {
+		t.Errorf("unexpected credentials: %v", creds)
+	}
+	if len(pf.Manifest.Inputs.DataSources) != 2 || pf.Manifest.Inputs.DataSources[1].Name != "non-breach" {
+		t.Errorf("unexpected manifest data sources: %v", pf.Manifest.Inputs.DataSources)
+	}
+	if err = pf.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParsePluginFileUnregisteredSource(t *testing.T) {
+	data := []byte(`
+plugin:
+  name: test
+  image_and_tag: test:1
+  compute_environment: {vcpu: 1, memory: 2048}
+  environment:
+    - name: A
+      value: B
+  volumes:
+    - name: models
+      resource_name: fs-1
+      read_only: true
+  manifest:
+    inputs:
+      data_sources:
+        - name: input
+          source: MISSING
+`)
+	if _, err := ParsePluginFile(data); err == nil {
+		t.Error("expected an unregistered source error")
+	}
+}
+
+func TestPluginFileSchema(t *testing.T) {
+	pf, err := LoadPluginFile("testdata/mmc-timing-plugin.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := pf.Plugin.Schema
+	if schema == nil || len(schema.Environment) != 6 || len(schema.DataSources) != 2 {
+		t.Fatalf("unexpected schema: %+v", schema)
+	}
+	if !schema.Environment[0].Required || schema.Environment[5].Name != "MMC_DELTA" || schema.Environment[5].Required {
+		t.Errorf("unexpected environment schema: %+v", schema.Environment)
+	}
+}
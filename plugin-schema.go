@@ -0,0 +1,283 @@
+package cloudcompute
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type SchemaType string
+
+const (
+	SchemaTypeString   SchemaType = "string"
+	SchemaTypeInt      SchemaType = "int"
+	SchemaTypeFloat    SchemaType = "float"
+	SchemaTypeBool     SchemaType = "bool"
+	SchemaTypeDateTime SchemaType = "datetime"
+)
+
+// PluginSchema describes the manifest inputs a plugin expects
+type PluginSchema struct {
+	Environment SchemaFields       `json:"environment" yaml:"environment"`
+	Parameters  SchemaFields       `json:"parameters" yaml:"parameters"`
+	DataSources []SchemaDataSource `json:"data_sources" yaml:"data_sources"`
+}
+
+// SchemaField is a single environment variable or parameter expected by a plugin.
+// For datetime fields the Format is a Go time layout (default is RFC3339).
+// For string fields the Format is an optional regular expression the value must match.
+type SchemaField struct {
+	Name        string     `json:"name" yaml:"name"`
+	Required    bool       `json:"required" yaml:"required"`
+	Type        SchemaType `json:"type" yaml:"type"` //default is string
+	Format      string     `json:"format,omitempty" yaml:"format"`
+	Description string     `json:"description,omitempty" yaml:"description"`
+	Default     string     `json:"default,omitempty" yaml:"default"`
+}
+
+// A data source a plugin expects in the manifest.
+// Source is the name of a registered source.
+type SchemaDataSource struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+	Source      string `json:"source" yaml:"source"`
+	Optional    bool   `json:"optional,omitempty" yaml:"optional"`
+}
+
+// ValidationErrors collects every problem found during a validation
+type ValidationErrors []string
+
+func (ve ValidationErrors) Error() string {
+	return strings.Join(ve, "; ")
+}
+
+// Validates a manifest against a plugin schema.
+// Checks that required environment variables, parameters and data sources are present
+// and that the values provided match the declared types and formats.
+// Fields with a schema default are not required in the manifest.
+// All problems are reported as ValidationErrors.
+func (cm *ComputeManifest) ValidateAgainst(schema PluginSchema) error {
+	return cm.validateAgainst(schema, nil, nil)
+}
+
+// Validates a manifest against the schema of a plugin.  The plugin default environment is
+// registered with the job definition and the plugin default parameters and schema defaults
+// are submitted with each job, so they satisfy required fields the manifest does not set.
+// Plugins without a schema are not validated.
+func (cm *ComputeManifest) ValidateAgainstPlugin(plugin Plugin) error {
+	if plugin.Schema == nil {
+		return nil
+	}
+	return cm.validateAgainst(*plugin.Schema, plugin.DefaultEnvironment, plugin.Parameters)
+}
+
+// Returns the parameters submitted with a manifest job.  Schema defaults are overridden
+// by the plugin default parameters, which are overridden by the manifest parameters.
+func (p *Plugin) jobParameters(manifest *ComputeManifest) map[string]string {
+	if len(p.Parameters) == 0 && (p.Schema == nil || len(p.Schema.Parameters) == 0) {
+		return manifest.Inputs.Parameters
+	}
+	params := make(map[string]string)
+	if p.Schema != nil {
+		for _, f := range p.Schema.Parameters {
+			if f.Default != "" {
+				params[f.Name] = f.Default
+			}
+		}
+	}
+	for k, v := range p.Parameters {
+		params[k] = v
+	}
+	for k, v := range manifest.Inputs.Parameters {
+		params[k] = v
+	}
+	return params
+}
+
+// Returns the schema default environment variables that are not set by the job
+// environment or the plugin default environment registered with the job definition.
+func (p *Plugin) schemaEnvironment(env KeyValuePairs) []KeyValuePair {
+	defaults := []KeyValuePair{}
+	if p.Schema == nil {
+		return defaults
+	}
+	registered := KeyValuePairs(p.DefaultEnvironment)
+	for _, f := range p.Schema.Environment {
+		if f.Default != "" && !env.HasKey(f.Name) && !registered.HasKey(f.Name) {
+			defaults = append(defaults, KeyValuePair{f.Name, f.Default})
+		}
+	}
+	return defaults
+}
+
+func (cm *ComputeManifest) validateAgainst(schema PluginSchema, defaultEnv []KeyValuePair, defaultParams map[string]string) error {
+	errs := ValidationErrors{}
+	env := make(map[string]string)
+	for _, kvp := range defaultEnv {
+		env[kvp.Name] = kvp.Value
+	}
+	for _, kvp := range cm.Inputs.Environment {
+		env[kvp.Name] = kvp.Value
+	}
+	params := make(map[string]string)
+	for k, v := range defaultParams {
+		params[k] = v
+	}
+	for k, v := range cm.Inputs.Parameters {
+		params[k] = v
+	}
+	for _, f := range schema.Environment {
+		errs = append(errs, f.validate("environment variable", env)...)
+	}
+	for _, f := range schema.Parameters {
+		errs = append(errs, f.validate("parameter", params)...)
+	}
+	for _, sds := range schema.DataSources {
+		if sds.Optional {
+			continue
+		}
+		found := false
+		for _, ds := range cm.Inputs.DataSources {
+			if ds.Name == sds.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, fmt.Sprintf("missing required data source %s", sds.Name))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("Invalid manifest %s: %w", cm.ManifestName, errs)
+	}
+	return nil
+}
+
+func (f SchemaField) validate(kind string, values map[string]string) []string {
+	val, ok := values[f.Name]
+	if !ok {
+		if f.Default == "" {
+			if f.Required {
+				return []string{fmt.Sprintf("missing required %s %s", kind, f.Name)}
+			}
+			return nil
+		}
+		val = f.Default
+	}
+	err := f.ValidateValue(val)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid %s %s: %s", kind, f.Name, err)}
+	}
+	return nil
+}
+
+// Validates a single value against the field type and format
+func (f SchemaField) ValidateValue(val string) error {
+	var err error
+	switch f.Type {
+	case "", SchemaTypeString:
+		if f.Format != "" {
+			var re *regexp.Regexp
+			re, err = regexp.Compile(f.Format)
+			if err == nil && !re.MatchString(val) {
+				err = fmt.Errorf("%q does not match %s", val, f.Format)
+			}
+		}
+	case SchemaTypeInt:
+		_, err = strconv.ParseInt(val, 10, 64)
+	case SchemaTypeFloat:
+		_, err = strconv.ParseFloat(val, 64)
+	case SchemaTypeBool:
+		_, err = strconv.ParseBool(val)
+	case SchemaTypeDateTime:
+		layout := f.Format
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		_, err = time.Parse(layout, val)
+	default:
+		err = fmt.Errorf("unsupported type %s", f.Type)
+	}
+	return err
+}
+
+// SchemaFields can be written in YAML as a sequence of single entry mappings
+//
+//   - MMC_FAIL_PLAN: required
+//
+// or as a mapping of names to field definitions
+//
+//	MMC_BREACH_TIME:
+//	  type: datetime
+//	  format: 02Jan2006 15:04:05
+//
+// A scalar field definition is "required", "optional", a type name, or a default value.
+type SchemaFields []SchemaField
+
+func (sf *SchemaFields) UnmarshalYAML(node *yaml.Node) error {
+	fields := SchemaFields{}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content); i += 2 {
+			f, err := decodeSchemaField(node.Content[i], node.Content[i+1])
+			if err != nil {
+				return err
+			}
+			fields = append(fields, f)
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if item.Kind != yaml.MappingNode {
+				return fmt.Errorf("line %d: schema fields must be mappings", item.Line)
+			}
+			//a full field definition includes the name
+			if len(item.Content) != 2 || item.Content[0].Value == "name" {
+				var f SchemaField
+				err := item.Decode(&f)
+				if err != nil {
+					return err
+				}
+				fields = append(fields, f)
+				continue
+			}
+			f, err := decodeSchemaField(item.Content[0], item.Content[1])
+			if err != nil {
+				return err
+			}
+			fields = append(fields, f)
+		}
+	default:
+		return fmt.Errorf("line %d: schema fields must be a mapping or a sequence", node.Line)
+	}
+	*sf = fields
+	return nil
+}
+
+func decodeSchemaField(key *yaml.Node, val *yaml.Node) (SchemaField, error) {
+	f := SchemaField{Name: key.Value}
+	switch val.Kind {
+	case yaml.ScalarNode:
+		switch v := SchemaType(val.Value); v {
+		case "required":
+			f.Required = true
+		case "optional", "":
+		case SchemaTypeString, SchemaTypeInt, SchemaTypeFloat, SchemaTypeBool, SchemaTypeDateTime:
+			f.Type = v
+		default:
+			f.Default = val.Value
+		}
+	case yaml.MappingNode:
+		err := val.Decode(&f)
+		if err != nil {
+			return f, err
+		}
+		f.Name = key.Value
+	default:
+		return f, fmt.Errorf("line %d: invalid definition for %s", val.Line, key.Value)
+	}
+	return f, nil
+}
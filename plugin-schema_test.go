@@ -0,0 +1,122 @@
+package cloudcompute
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/usace/cc-go-sdk"
+	"gopkg.in/yaml.v3"
+)
+
+func TestValidateAgainst(t *testing.T) {
+	schema := PluginSchema{
+		Environment: SchemaFields{
+			{Name: "MMC_FAIL_PLAN", Required: true},
+			{Name: "MMC_BREACH_TIME", Required: true, Type: SchemaTypeDateTime, Format: "02Jan2006 15:04:05"},
+			{Name: "MMC_DELTA", Type: SchemaTypeFloat},
+		},
+		Parameters:  SchemaFields{{Name: "param1", Required: true}},
+		DataSources: []SchemaDataSource{{Name: "breach"}, {Name: "non-breach", Optional: true}},
+	}
+	manifest := ComputeManifest{
+		ManifestName: "mmc-timing",
+		Inputs: PluginInputs{
+			Environment: KeyValuePairs{
+				{"MMC_FAIL_PLAN", "model.p01.hdf"},
+				{"MMC_BREACH_TIME", "06FEB2099 14:20:00"},
+				{"MMC_DELTA", "2.0"},
+			},
+			Parameters:  map[string]string{"param1": "--cmd"},
+			DataSources: []DataSource{{Name: "breach"}},
+		},
+	}
+	if err := manifest.ValidateAgainst(schema); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	manifest.Inputs.Environment = KeyValuePairs{
+		{"MMC_BREACH_TIME", "2099-02-06"},
+		{"MMC_DELTA", "two"},
+	}
+	manifest.Inputs.Parameters = nil
+	manifest.Inputs.DataSources = nil
+	err := manifest.ValidateAgainst(schema)
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("expected validation errors, got %v", err)
+	}
+	if len(verrs) != 5 {
+		t.Errorf("expected 5 validation errors, got %d: %s", len(verrs), verrs)
+	}
+}
+
+func TestValidateAgainstPluginDefaults(t *testing.T) {
+	plugin := Plugin{
+		Name:               "mmc-timing",
+		DefaultEnvironment: []KeyValuePair{{"MMC_FAIL_PLAN", "model.p01.hdf"}},
+		Parameters:         map[string]string{"param1": "--cmd"},
+		Schema: &PluginSchema{
+			Environment: SchemaFields{
+				{Name: "MMC_FAIL_PLAN", Required: true},
+				{Name: "MMC_DELTA", Required: true, Type: SchemaTypeFloat, Default: "2.0"},
+			},
+			Parameters: SchemaFields{{Name: "param1", Required: true}, {Name: "mode", Default: "fast"}},
+		},
+	}
+	manifest := ComputeManifest{ManifestName: "mmc-timing", PluginDefinition: "mmc-timing:2"}
+	if err := manifest.ValidateAgainstPlugin(plugin); err != nil {
+		t.Errorf("expected the plugin and schema defaults to satisfy required fields: %s", err)
+	}
+	if err := manifest.ValidateAgainst(*plugin.Schema); err == nil {
+		t.Error("expected missing required fields without the plugin defaults")
+	}
+	cc := CloudCompute{Plugins: map[string]Plugin{"mmc-timing": plugin}}
+	job := cc.newJob(Event{}, manifest, nil)
+	env := KeyValuePairs(job.ContainerOverrides.Environment)
+	if env.GetVal("MMC_DELTA") != "2.0" || env.HasKey("MMC_FAIL_PLAN") {
+		t.Errorf("expected the schema default environment in the job: %v", env)
+	}
+	if job.Parameters["param1"] != "--cmd" || job.Parameters["mode"] != "fast" {
+		t.Errorf("expected the default parameters in the job: %v", job.Parameters)
+	}
+	manifest.Inputs.Environment = KeyValuePairs{{"MMC_DELTA", "two"}}
+	if err := manifest.ValidateAgainstPlugin(plugin); err == nil {
+		t.Error("expected the manifest value to be validated in place of the default")
+	}
+}
+
+func TestSchemaFieldsYaml(t *testing.T) {
+	data := []byte(`
+environment:
+  - MMC_FAIL_PLAN: required
+  - MMC_DELTA: optional
+  - name: MMC_SCENARIO
+    required: true
+parameters:
+  param1: --cmd2
+  count: int
+  start:
+    type: datetime
+    required: true
+`)
+	var schema PluginSchema
+	if err := yaml.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	env := schema.Environment
+	if len(env) != 3 || !env[0].Required || env[1].Required || env[2].Name != "MMC_SCENARIO" || !env[2].Required {
+		t.Errorf("unexpected environment schema: %+v", env)
+	}
+	params := schema.Parameters
+	if len(params) != 3 || params[0].Default != "--cmd2" || params[1].Type != SchemaTypeInt || params[2].Name != "start" || !params[2].Required {
+		t.Errorf("unexpected parameter schema: %+v", params)
+	}
+}
+
+func TestPluginName(t *testing.T) {
+	for _, def := range []string{"mmc-timing", "mmc-timing:3", "arn:aws:batch:us-east-1:123456789012:job-definition/mmc-timing:3"} {
+		if name := PluginName(def); name != "mmc-timing" {
+			t.Errorf("expected mmc-timing from %s, got %s", def, name)
+		}
+	}
+}
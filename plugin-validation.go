@@ -0,0 +1,196 @@
+package cloudcompute
+
+import (
+	"fmt"
+	"strconv"
+)
+
+var validUlimits = map[string]bool{
+	"core": true, "cpu": true, "data": true, "fsize": true, "locks": true,
+	"memlock": true, "msgqueue": true, "nice": true, "nofile": true, "nproc": true,
+	"rss": true, "rtprio": true, "rttime": true, "sigpending": true, "stack": true,
+}
+
+var validDevicePermissions = map[string]bool{
+	"READ": true, "WRITE": true, "MKNOD": true,
+}
+
+// Validates a plugin prior to registration with a compute provider.
+// Returns the first problem found.
+func (p *Plugin) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("Invalid plugin: missing name")
+	}
+	if p.ImageAndTag == "" {
+		return fmt.Errorf("Invalid plugin %s: missing image", p.Name)
+	}
+	platform := p.Platform()
+	switch platform {
+	case PlatformEc2:
+		if p.AssignPublicIp || p.PlatformVersion != "" {
+			return fmt.Errorf("Invalid plugin %s: public ip assignment and platform version are only supported on Fargate", p.Name)
+		}
+	case PlatformFargate, PlatformFargateSpot:
+		for _, v := range p.Volumes {
+			if v.VolumeType() == VolumeTypeHost {
+				return fmt.Errorf("Invalid plugin %s: host volume %s is not supported on Fargate", p.Name, v.Name)
+			}
+		}
+	default:
+		return fmt.Errorf("Invalid plugin %s: unsupported platform capability %s", p.Name, p.PlatformCapability)
+	}
+	err := p.ComputeEnvironment.Validate(platform)
+	if err != nil {
+		return fmt.Errorf("Invalid plugin %s: %s", p.Name, err)
+	}
+	return validateVolumes(p.Volumes)
+}
+
+// Validates the compute environment resources individually and against the platform rules.
+// Fargate requires one of the supported vcpu/memory pairs and does not support gpus,
+// shared memory, devices or ulimits other than nofile.
+// Ephemeral storage is only available on Fargate.
+func (pce PluginComputeEnvironment) Validate(platform PlatformCapability) error {
+	vcpu, err := strconv.ParseFloat(pce.VCPU, 64)
+	if err != nil || vcpu <= 0 {
+		return fmt.Errorf("invalid vcpu value %q", pce.VCPU)
+	}
+	memory, err := strconv.ParseInt(pce.Memory, 10, 32)
+	if err != nil || memory <= 0 {
+		return fmt.Errorf("invalid memory value %q", pce.Memory)
+	}
+	if pce.GPU != "" {
+		gpu, err := strconv.ParseInt(pce.GPU, 10, 32)
+		if err != nil || gpu < 0 {
+			return fmt.Errorf("invalid gpu value %q", pce.GPU)
+		}
+	}
+	if pce.SharedMemory < 0 || int64(pce.SharedMemory) >= memory {
+		return fmt.Errorf("shared memory of %d MiB must be less than the container memory of %d MiB", pce.SharedMemory, memory)
+	}
+	if platform.IsFargate() {
+		if pce.EphemeralStorage != 0 && (pce.EphemeralStorage < 21 || pce.EphemeralStorage > 200) {
+			return fmt.Errorf("ephemeral storage of %d GiB must be between 21 and 200 GiB", pce.EphemeralStorage)
+		}
+		if (pce.GPU != "" && pce.GPU != "0") || pce.SharedMemory > 0 || len(pce.Devices) > 0 {
+			return fmt.Errorf("Fargate does not support gpus, shared memory, or devices")
+		}
+		for _, ul := range pce.Ulimits {
+			if ul.Name != "nofile" {
+				return fmt.Errorf("Fargate only supports the nofile ulimit")
+			}
+		}
+		err = validateFargateResources(vcpu, memory)
+		if err != nil {
+			return err
+		}
+	} else if pce.EphemeralStorage != 0 {
+		return fmt.Errorf("ephemeral storage is only supported on Fargate")
+	}
+	ulimits := make(map[string]bool)
+	for _, ul := range pce.Ulimits {
+		if !validUlimits[ul.Name] {
+			return fmt.Errorf("invalid ulimit %q", ul.Name)
+		}
+		if ulimits[ul.Name] {
+			return fmt.Errorf("duplicate ulimit %q", ul.Name)
+		}
+		ulimits[ul.Name] = true
+		if ul.SoftLimit > ul.HardLimit {
+			return fmt.Errorf("ulimit %s soft limit %d exceeds the hard limit %d", ul.Name, ul.SoftLimit, ul.HardLimit)
+		}
+	}
+	for _, d := range pce.Devices {
+		if d.HostPath == "" {
+			return fmt.Errorf("device is missing a host path")
+		}
+		for _, perm := range d.Permissions {
+			if !validDevicePermissions[perm] {
+				return fmt.Errorf("invalid permission %q for device %s", perm, d.HostPath)
+			}
+		}
+	}
+	return nil
+}
+
+// Fargate only supports specific memory values for each vcpu value
+func validateFargateResources(vcpu float64, memory int64) error {
+	for _, m := range fargateMemoryValues(vcpu) {
+		if m == memory {
+			return nil
+		}
+	}
+	return fmt.Errorf("%d MiB of memory with %v vcpu is not a valid Fargate combination", memory, vcpu)
+}
+
+func fargateMemoryValues(vcpu float64) []int64 {
+	switch vcpu {
+	case 0.25:
+		return []int64{512, 1024, 2048}
+	case 0.5:
+		return memoryRange(1024, 4096, 1024)
+	case 1:
+		return memoryRange(2048, 8192, 1024)
+	case 2:
+		return memoryRange(4096, 16384, 1024)
+	case 4:
+		return memoryRange(8192, 30720, 1024)
+	case 8:
+		return memoryRange(16384, 61440, 4096)
+	case 16:
+		return memoryRange(32768, 122880, 8192)
+	}
+	return nil
+}
+
+func memoryRange(from int64, to int64, step int64) []int64 {
+	vals := []int64{}
+	for m := from; m <= to; m += step {
+		vals = append(vals, m)
+	}
+	return vals
+}
+
+func validateVolumes(volumes []PluginComputeVolumes) error {
+	names := make(map[string]bool)
+	mountPoints := make(map[string]string)
+	for _, v := range volumes {
+		if v.Name == "" {
+			return fmt.Errorf("Invalid volume: missing name for volume mounted at %s", v.ContainerPath())
+		}
+		if names[v.Name] {
+			return fmt.Errorf("Invalid volume %s: duplicate volume name", v.Name)
+		}
+		names[v.Name] = true
+
+		mp := v.ContainerPath()
+		if other, ok := mountPoints[mp]; ok {
+			return fmt.Errorf("Invalid volume %s: mount point %s is already used by volume %s", v.Name, mp, other)
+		}
+		mountPoints[mp] = v.Name
+
+		switch v.VolumeType() {
+		case VolumeTypeEfs:
+			if v.ResourceName == "" {
+				return fmt.Errorf("Invalid volume %s: missing EFS file system id", v.Name)
+			}
+			if v.AccessPointId != "" && v.RootDirectory != "" && v.RootDirectory != "/" {
+				return fmt.Errorf("Invalid volume %s: root directory must be empty or \"/\" when using an access point", v.Name)
+			}
+		case VolumeTypeHost:
+			if v.ResourceName == "" {
+				return fmt.Errorf("Invalid volume %s: missing host source path", v.Name)
+			}
+			if v.AccessPointId != "" || v.RootDirectory != "" {
+				return fmt.Errorf("Invalid volume %s: access points and root directories are only supported for EFS volumes", v.Name)
+			}
+		case VolumeTypeLocal:
+			if v.ResourceName != "" || v.AccessPointId != "" || v.RootDirectory != "" {
+				return fmt.Errorf("Invalid volume %s: local volumes do not use a resource name, access point or root directory", v.Name)
+			}
+		default:
+			return fmt.Errorf("Invalid volume %s: unsupported volume type %s", v.Name, v.Type)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package cloudcompute
+
+import "testing"
+
+func TestValidateVolumes(t *testing.T) {
+	tests := []struct {
+		name    string
+		volumes []PluginComputeVolumes
+		valid   bool
+	}{
+		{"efs", []PluginComputeVolumes{{Name: "a", ResourceName: "fs-1"}}, true},
+		{"missing filesystem", []PluginComputeVolumes{{Name: "a"}}, false},
+		{"duplicate name", []PluginComputeVolumes{{Name: "a", ResourceName: "fs-1"}, {Name: "a", ResourceName: "fs-2", MountPoint: "/b"}}, false},
+		{"duplicate default mount point", []PluginComputeVolumes{{Name: "a", ResourceName: "fs-1"}, {Name: "b", ResourceName: "fs-2"}}, false},
+		{"access point root", []PluginComputeVolumes{{Name: "a", ResourceName: "fs-1", AccessPointId: "fsap-1", RootDirectory: "/models"}}, false},
+		{"host access point", []PluginComputeVolumes{{Name: "a", Type: VolumeTypeHost, ResourceName: "/tmp", AccessPointId: "fsap-1"}}, false},
+		{"unknown type", []PluginComputeVolumes{{Name: "a", Type: "NFS", ResourceName: "server"}}, false},
+	}
+	for _, test := range tests {
+		err := validateVolumes(test.volumes)
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected a validation error", test.name)
+		}
+	}
+}
+
+func TestValidateComputeEnvironment(t *testing.T) {
+	tests := []struct {
+		name     string
+		platform PlatformCapability
+		pce      PluginComputeEnvironment
+		valid    bool
+	}{
+		{"basic", PlatformEc2, PluginComputeEnvironment{VCPU: "1", Memory: "2048"}, true},
+		{"missing memory", PlatformEc2, PluginComputeEnvironment{VCPU: "1"}, false},
+		{"gpu", PlatformEc2, PluginComputeEnvironment{VCPU: "4", Memory: "16000", GPU: "1", SharedMemory: 1024}, true},
+		{"bad gpu", PlatformEc2, PluginComputeEnvironment{VCPU: "4", Memory: "16000", GPU: "one"}, false},
+		{"shared memory too large", PlatformEc2, PluginComputeEnvironment{VCPU: "1", Memory: "2048", SharedMemory: 2048}, false},
+		{"ec2 storage", PlatformEc2, PluginComputeEnvironment{VCPU: "1", Memory: "2048", EphemeralStorage: 50}, false},
+		{"fargate", PlatformFargate, PluginComputeEnvironment{VCPU: "0.25", Memory: "512"}, true},
+		{"fargate storage", PlatformFargateSpot, PluginComputeEnvironment{VCPU: "0.5", Memory: "3072", EphemeralStorage: 50}, true},
+		{"fargate invalid pair", PlatformFargate, PluginComputeEnvironment{VCPU: "0.5", Memory: "8192"}, false},
+		{"fargate gpu", PlatformFargate, PluginComputeEnvironment{VCPU: "4", Memory: "8192", GPU: "1"}, false},
+		{"fargate ulimit", PlatformFargate, PluginComputeEnvironment{VCPU: "1", Memory: "2048", Ulimits: []PluginUlimit{{"nproc", 1, 1}}}, false},
+		{"storage out of range", PlatformFargate, PluginComputeEnvironment{VCPU: "1", Memory: "2048", EphemeralStorage: 10}, false},
+		{"ulimit", PlatformEc2, PluginComputeEnvironment{VCPU: "1", Memory: "2048", Ulimits: []PluginUlimit{{"nofile", 1024, 4096}}}, true},
+		{"ulimit soft over hard", PlatformEc2, PluginComputeEnvironment{VCPU: "1", Memory: "2048", Ulimits: []PluginUlimit{{"nofile", 8192, 4096}}}, false},
+		{"unknown ulimit", PlatformEc2, PluginComputeEnvironment{VCPU: "1", Memory: "2048", Ulimits: []PluginUlimit{{"files", 1, 1}}}, false},
+		{"device permission", PlatformEc2, PluginComputeEnvironment{VCPU: "1", Memory: "2048", Devices: []PluginDevice{{HostPath: "/dev/fuse", Permissions: []string{"EXECUTE"}}}}, false},
+	}
+	for _, test := range tests {
+		err := test.pce.Validate(test.platform)
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected a validation error", test.name)
+		}
+	}
+}
+
+func TestValidatePluginPlatform(t *testing.T) {
+	plugin := Plugin{
+		Name:               "preprocess",
+		ImageAndTag:        "preprocess:1.0",
+		ComputeEnvironment: PluginComputeEnvironment{VCPU: "1", Memory: "2048"},
+		PlatformCapability: PlatformFargate,
+		AssignPublicIp:     true,
+	}
+	if err := plugin.Validate(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	plugin.Volumes = []PluginComputeVolumes{{Name: "scratch", Type: VolumeTypeHost, ResourceName: "/tmp"}}
+	if plugin.Validate() == nil {
+		t.Errorf("host volumes should not be allowed on Fargate")
+	}
+	plugin.Volumes = nil
+	plugin.PlatformCapability = PlatformEc2
+	if plugin.Validate() == nil {
+		t.Errorf("public ip assignment should not be allowed on EC2")
+	}
+	plugin.PlatformCapability = "LAMBDA"
+	if plugin.Validate() == nil {
+		t.Errorf("expected an unsupported platform error")
+	}
+}
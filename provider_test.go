@@ -0,0 +1,80 @@
+package cloudcompute
+
+import (
+	"fmt"
+	"sync"
+
+	. "github.com/usace/cc-go-sdk"
+)
+
+// testProvider is an in memory ComputeProvider that records submitted jobs
+type testProvider struct {
+	mu        sync.Mutex
+	jobs      []Job
+	summaries []JobSummary
+	submitErr func(job *Job) error
+}
+
+func (tp *testProvider) SubmitJob(job *Job) error {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if tp.submitErr != nil {
+		if err := tp.submitErr(job); err != nil {
+			return err
+		}
+	}
+	id := fmt.Sprintf("job-%d", len(tp.jobs)+1)
+	job.SubmittedJob = &SubmitJobResult{JobId: &id}
+	tp.jobs = append(tp.jobs, *job)
+	return nil
+}
+
+func (tp *testProvider) TerminateJobs(input TermminateJobInput) error {
+	return nil
+}
+
+func (tp *testProvider) Status(jobQueue string, query JobsSummaryQuery) error {
+	tp.mu.Lock()
+	summaries := append([]JobSummary{}, tp.summaries...)
+	tp.mu.Unlock()
+	query.JobSummaryFunction(summaries)
+	return nil
+}
+
+func (tp *testProvider) JobLog(submittedJobId string) ([]string, error) {
+	return nil, nil
+}
+
+func (tp *testProvider) RegisterPlugin(plugin *Plugin) (PluginRegistrationOutput, error) {
+	return PluginRegistrationOutput{}, nil
+}
+
+func (tp *testProvider) EnsurePlugin(plugin *Plugin) (EnsurePluginOutput, error) {
+	return EnsurePluginOutput{}, nil
+}
+
+func (tp *testProvider) GetPlugin(nameAndRevision string) (Plugin, error) {
+	return Plugin{}, nil
+}
+
+func (tp *testProvider) ListPlugins(filter PluginFilter) ([]Plugin, error) {
+	return nil, nil
+}
+
+func (tp *testProvider) UnregisterPlugin(nameAndRevision string) error {
+	return nil
+}
+
+func (tp *testProvider) PrunePlugins(input PrunePluginsInput) (PrunePluginsOutput, error) {
+	return PrunePluginsOutput{}, nil
+}
+
+func (tp *testProvider) submitted() []Job {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	return append([]Job{}, tp.jobs...)
+}
+
+func jobEventNumber(job Job) string {
+	return KeyValuePairs(job.ContainerOverrides.Environment).GetVal(CcEventNumber)
+}
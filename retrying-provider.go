@@ -0,0 +1,415 @@
+package cloudcompute
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// ComputeProvider API names used for rate limits and retry metrics
+const (
+	ApiSubmitJob        string = "SubmitJob"
+	ApiTerminateJobs    string = "TerminateJobs"
+	ApiStatus           string = "Status"
+	ApiQueueSummary     string = "QueueSummary"
+	ApiJobLog           string = "JobLog"
+	ApiRegisterPlugin   string = "RegisterPlugin"
+	ApiEnsurePlugin     string = "EnsurePlugin"
+	ApiGetPlugin        string = "GetPlugin"
+	ApiListPlugins      string = "ListPlugins"
+	ApiUnregisterPlugin string = "UnregisterPlugin"
+	ApiPrunePlugins     string = "PrunePlugins"
+)
+
+// error codes returned by compute providers when requests are throttled
+var throttlingErrorCodes = map[string]bool{
+	"TooManyRequestsException":               true,
+	"ThrottlingException":                    true,
+	"Throttling":                             true,
+	"ThrottledException":                     true,
+	"RequestLimitExceeded":                   true,
+	"RequestThrottled":                       true,
+	"RequestThrottledException":              true,
+	"ProvisionedThroughputExceededException": true,
+	"SlowDown":                               true,
+}
+
+// error codes for transient provider failures
+var transientErrorCodes = map[string]bool{
+	"ServerException":         true,
+	"InternalServerError":     true,
+	"InternalFailure":         true,
+	"ServiceUnavailable":      true,
+	"RequestTimeout":          true,
+	"RequestTimeoutException": true,
+}
+
+// RateLimit is a token bucket limit for a provider API.
+// Rate is the sustained number of calls per second and Burst is the number
+// of calls that can be made at once (default is 1).
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RetryPolicy is an exponential backoff with full jitter.
+// The delay before retry n is a random duration up to BaseDelay * 2^n, capped at MaxDelay.
+type RetryPolicy struct {
+	//total attempts including the first call.  default is 8
+	MaxAttempts int
+
+	//default is 200 milliseconds
+	BaseDelay time.Duration
+
+	//default is 30 seconds
+	MaxDelay time.Duration
+}
+
+type RetryingProviderInput struct {
+	//the provider being wrapped
+	Provider ComputeProvider
+
+	//Optional. rate limits keyed by API name (the Api* constants).  APIs without a limit are not limited
+	RateLimits map[string]RateLimit
+
+	Retry RetryPolicy
+
+	//Optional. determines if an error can be retried.  The default retries throttling
+	//and transient server errors and network timeouts.
+	IsRetryable func(err error) bool
+}
+
+// ApiMetrics are the call counts for a single provider API
+type ApiMetrics struct {
+	Calls     int64
+	Retries   int64
+	Throttled int64
+	Failed    int64
+
+	//total time spent waiting on the rate limiter and retry backoff
+	Waited time.Duration
+}
+
+// RetryingProvider is a ComputeProvider decorator that rate limits each API with a token bucket
+// and retries throttled and transient failures with exponential backoff and jitter.
+//
+// Providers that report PagesSummaries (e.g. AwsBatchProvider) are rate limited and retried for each
+// Status and QueueSummary page request.  Other providers are rate limited once for each call and
+// retried from the first page, skipping the pages already delivered to the JobSummaryFunction.
+// A SubmitJob that times out may have created the job, so retrying it can submit a duplicate job.
+// Use IsRetryable to limit retries to throttling errors (IsThrottlingError) when duplicates are not acceptable.
+type RetryingProvider struct {
+	provider    ComputeProvider
+	limits      map[string]*tokenBucket
+	retry       RetryPolicy
+	isRetryable func(err error) bool
+
+	mu      sync.Mutex
+	metrics map[string]*ApiMetrics
+	rng     *rand.Rand
+
+	//replaced in tests
+	sleep func(d time.Duration)
+	now   func() time.Time
+}
+
+// Wraps a compute provider with rate limiting and retries
+func NewRetryingProvider(input RetryingProviderInput) *RetryingProvider {
+	retry := input.Retry
+	if retry.MaxAttempts <= 0 {
+		retry.MaxAttempts = 8
+	}
+	if retry.BaseDelay <= 0 {
+		retry.BaseDelay = 200 * time.Millisecond
+	}
+	if retry.MaxDelay <= 0 {
+		retry.MaxDelay = 30 * time.Second
+	}
+	rp := RetryingProvider{
+		provider:    input.Provider,
+		limits:      make(map[string]*tokenBucket),
+		retry:       retry,
+		isRetryable: input.IsRetryable,
+		metrics:     make(map[string]*ApiMetrics),
+		rng:         rand.New(rand.NewSource(time.Now().UnixNano())),
+		sleep:       time.Sleep,
+		now:         time.Now,
+	}
+	if rp.isRetryable == nil {
+		rp.isRetryable = IsRetryableError
+	}
+	for api, limit := range input.RateLimits {
+		burst := limit.Burst
+		if burst <= 0 {
+			burst = 1
+		}
+		rp.limits[api] = &tokenBucket{rate: limit.Rate, burst: float64(burst), tokens: float64(burst)}
+	}
+	return &rp
+}
+
+// Returns a copy of the metrics for each API called
+func (rp *RetryingProvider) Metrics() map[string]ApiMetrics {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	metrics := make(map[string]ApiMetrics, len(rp.metrics))
+	for api, m := range rp.metrics {
+		metrics[api] = *m
+	}
+	return metrics
+}
+
+// Determines if an error is a throttling error, a transient server error, or a network timeout
+func IsRetryableError(err error) bool {
+	return IsThrottlingError(err) || isTransientError(err)
+}
+
+// Determines if an error is a provider throttling error
+func IsThrottlingError(err error) bool {
+	var coded interface{ ErrorCode() string }
+	if errors.As(err, &coded) && throttlingErrorCodes[coded.ErrorCode()] {
+		return true
+	}
+	var status interface{ HTTPStatusCode() int }
+	return errors.As(err, &status) && status.HTTPStatusCode() == 429
+}
+
+func isTransientError(err error) bool {
+	var coded interface{ ErrorCode() string }
+	if errors.As(err, &coded) && transientErrorCodes[coded.ErrorCode()] {
+		return true
+	}
+	var status interface{ HTTPStatusCode() int }
+	if errors.As(err, &status) && status.HTTPStatusCode() >= 500 {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// calls f with rate limiting and retries
+func (rp *RetryingProvider) call(api string, f func() error) error {
+	var err error
+	for attempt := 0; attempt < rp.retry.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			delay := rp.backoff(attempt)
+			rp.record(api, func(m *ApiMetrics) {
+				m.Retries++
+				m.Waited += delay
+			})
+			rp.sleep(delay)
+		}
+		if limit, ok := rp.limits[api]; ok {
+			if wait := limit.reserve(rp.now()); wait > 0 {
+				rp.record(api, func(m *ApiMetrics) { m.Waited += wait })
+				rp.sleep(wait)
+			}
+		}
+		rp.record(api, func(m *ApiMetrics) { m.Calls++ })
+		err = f()
+		if err == nil {
+			return nil
+		}
+		if IsThrottlingError(err) {
+			rp.record(api, func(m *ApiMetrics) { m.Throttled++ })
+		}
+		if !rp.isRetryable(err) {
+			break
+		}
+	}
+	rp.record(api, func(m *ApiMetrics) { m.Failed++ })
+	return err
+}
+
+// full jitter exponential backoff
+func (rp *RetryingProvider) backoff(attempt int) time.Duration {
+	max := float64(rp.retry.BaseDelay) * math.Pow(2, float64(attempt-1))
+	if max > float64(rp.retry.MaxDelay) {
+		max = float64(rp.retry.MaxDelay)
+	}
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return time.Duration(rp.rng.Float64() * max)
+}
+
+func (rp *RetryingProvider) record(api string, update func(m *ApiMetrics)) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	m, ok := rp.metrics[api]
+	if !ok {
+		m = &ApiMetrics{}
+		rp.metrics[api] = m
+	}
+	update(m)
+}
+
+func (rp *RetryingProvider) SubmitJob(job *Job) error {
+	return rp.call(ApiSubmitJob, func() error {
+		return rp.provider.SubmitJob(job)
+	})
+}
+
+func (rp *RetryingProvider) TerminateJobs(input TermminateJobInput) error {
+	return rp.call(ApiTerminateJobs, func() error {
+		return rp.provider.TerminateJobs(input)
+	})
+}
+
+func (rp *RetryingProvider) Status(jobQueue string, query JobsSummaryQuery) error {
+	return rp.pagedCall(ApiStatus, query, func(q JobsSummaryQuery) error {
+		return rp.provider.Status(jobQueue, q)
+	})
+}
+
+// Determines if the wrapped provider makes each summary page request through the query PageRequest
+func (rp *RetryingProvider) PagesSummaries() bool {
+	return pagesSummaries(rp.provider)
+}
+
+// rate limits and retries a paged summary request without delivering a page to the JobSummaryFunction twice
+func (rp *RetryingProvider) pagedCall(api string, query JobsSummaryQuery, f func(q JobsSummaryQuery) error) error {
+	if pagesSummaries(rp.provider) {
+		q := query
+		q.PageRequest = func(request func() error) error {
+			return rp.call(api, func() error {
+				return query.requestPage(request)
+			})
+		}
+		return f(q)
+	}
+	if query.JobSummaryFunction == nil {
+		return f(query)
+	}
+	delivered := 0
+	process := query.JobSummaryFunction
+	return rp.call(api, func() error {
+		page := 0
+		q := query
+		q.JobSummaryFunction = func(summaries []JobSummary) {
+			page++
+			if page > delivered {
+				delivered = page
+				process(summaries)
+			}
+		}
+		return f(q)
+	})
+}
+
+// Determines if the wrapped provider can list the active jobs in a queue
+func (rp *RetryingProvider) SupportsQueueSummary() bool {
+	return SupportsQueueSummary(rp.provider)
+}
+
+// Retries a queue summary of the wrapped provider.  Fails if the wrapped provider is not a QueueSummaryProvider.
+func (rp *RetryingProvider) QueueSummary(jobQueue string, query JobsSummaryQuery) error {
+	qsp, ok := rp.provider.(QueueSummaryProvider)
+	if !ok {
+		return fmt.Errorf("Compute provider does not support queue summaries")
+	}
+	return rp.pagedCall(ApiQueueSummary, query, func(q JobsSummaryQuery) error {
+		return qsp.QueueSummary(jobQueue, q)
+	})
+}
+
+func (rp *RetryingProvider) JobLog(submittedJobId string) ([]string, error) {
+	var log []string
+	err := rp.call(ApiJobLog, func() error {
+		var err error
+		log, err = rp.provider.JobLog(submittedJobId)
+		return err
+	})
+	return log, err
+}
+
+func (rp *RetryingProvider) RegisterPlugin(plugin *Plugin) (PluginRegistrationOutput, error) {
+	var output PluginRegistrationOutput
+	err := rp.call(ApiRegisterPlugin, func() error {
+		var err error
+		output, err = rp.provider.RegisterPlugin(plugin)
+		return err
+	})
+	return output, err
+}
+
+func (rp *RetryingProvider) EnsurePlugin(plugin *Plugin) (EnsurePluginOutput, error) {
+	var output EnsurePluginOutput
+	err := rp.call(ApiEnsurePlugin, func() error {
+		var err error
+		output, err = rp.provider.EnsurePlugin(plugin)
+		return err
+	})
+	return output, err
+}
+
+func (rp *RetryingProvider) GetPlugin(nameAndRevision string) (Plugin, error) {
+	var plugin Plugin
+	err := rp.call(ApiGetPlugin, func() error {
+		var err error
+		plugin, err = rp.provider.GetPlugin(nameAndRevision)
+		return err
+	})
+	return plugin, err
+}
+
+func (rp *RetryingProvider) ListPlugins(filter PluginFilter) ([]Plugin, error) {
+	var plugins []Plugin
+	err := rp.call(ApiListPlugins, func() error {
+		var err error
+		plugins, err = rp.provider.ListPlugins(filter)
+		return err
+	})
+	return plugins, err
+}
+
+func (rp *RetryingProvider) UnregisterPlugin(nameAndRevision string) error {
+	return rp.call(ApiUnregisterPlugin, func() error {
+		return rp.provider.UnregisterPlugin(nameAndRevision)
+	})
+}
+
+func (rp *RetryingProvider) PrunePlugins(input PrunePluginsInput) (PrunePluginsOutput, error) {
+	var output PrunePluginsOutput
+	err := rp.call(ApiPrunePlugins, func() error {
+		var err error
+		output, err = rp.provider.PrunePlugins(input)
+		return err
+	})
+	return output, err
+}
+
+// tokenBucket allows Rate calls per second with bursts of up to Burst calls.
+// Calls reserve a token and wait until the token is available, so callers are served in order.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// reserves a token and returns how long to wait before using it
+func (tb *tokenBucket) reserve(now time.Time) time.Duration {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	if tb.rate <= 0 {
+		return 0
+	}
+	if !tb.last.IsZero() {
+		tb.tokens = math.Min(tb.burst, tb.tokens+now.Sub(tb.last).Seconds()*tb.rate)
+	}
+	tb.last = now
+	tb.tokens--
+	if tb.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-tb.tokens / tb.rate * float64(time.Second))
+}
+
+func (m ApiMetrics) String() string {
+	return fmt.Sprintf("calls=%d retries=%d throttled=%d failed=%d waited=%s", m.Calls, m.Retries, m.Throttled, m.Failed, m.Waited)
+}
@@ -0,0 +1,186 @@
+package cloudcompute
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type testApiError struct {
+	code string
+}
+
+func (e testApiError) Error() string     { return e.code }
+func (e testApiError) ErrorCode() string { return e.code }
+
+func testRetryingProvider(provider ComputeProvider, input RetryingProviderInput) (*RetryingProvider, *[]time.Duration) {
+	input.Provider = provider
+	rp := NewRetryingProvider(input)
+	slept := []time.Duration{}
+	now := time.Unix(0, 0)
+	rp.sleep = func(d time.Duration) {
+		slept = append(slept, d)
+		now = now.Add(d)
+	}
+	rp.now = func() time.Time { return now }
+	return rp, &slept
+}
+
+func TestRetryingProviderRetriesThrottling(t *testing.T) {
+	attempts := 0
+	tp := &testProvider{submitErr: func(job *Job) error {
+		attempts++
+		if attempts < 3 {
+			return fmt.Errorf("submit failed: %w", testApiError{"TooManyRequestsException"})
+		}
+		return nil
+	}}
+	rp, slept := testRetryingProvider(tp, RetryingProviderInput{
+		Retry: RetryPolicy{BaseDelay: time.Second, MaxDelay: 2 * time.Second},
+	})
+	err := rp.SubmitJob(&Job{JobName: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tp.submitted()) != 1 {
+		t.Fatalf("expected 1 submitted job, got %d", len(tp.submitted()))
+	}
+	if len(*slept) != 2 || (*slept)[0] > time.Second || (*slept)[1] > 2*time.Second {
+		t.Errorf("unexpected backoff delays %v", *slept)
+	}
+	m := rp.Metrics()[ApiSubmitJob]
+	if m.Calls != 3 || m.Retries != 2 || m.Throttled != 2 || m.Failed != 0 {
+		t.Errorf("unexpected metrics %s", m)
+	}
+}
+
+func TestRetryingProviderStopsOnPermanentErrors(t *testing.T) {
+	permanent := errors.New("invalid job definition")
+	tp := &testProvider{submitErr: func(job *Job) error { return permanent }}
+	rp, _ := testRetryingProvider(tp, RetryingProviderInput{})
+	err := rp.SubmitJob(&Job{})
+	if !errors.Is(err, permanent) {
+		t.Fatalf("expected the provider error, got %v", err)
+	}
+	m := rp.Metrics()[ApiSubmitJob]
+	if m.Calls != 1 || m.Retries != 0 || m.Failed != 1 {
+		t.Errorf("unexpected metrics %s", m)
+	}
+}
+
+func TestRetryingProviderMaxAttempts(t *testing.T) {
+	tp := &testProvider{submitErr: func(job *Job) error { return testApiError{"ServerException"} }}
+	rp, _ := testRetryingProvider(tp, RetryingProviderInput{Retry: RetryPolicy{MaxAttempts: 4}})
+	err := rp.SubmitJob(&Job{})
+	if err == nil {
+		t.Fatal("expected an error after the last attempt")
+	}
+	m := rp.Metrics()[ApiSubmitJob]
+	if m.Calls != 4 || m.Retries != 3 || m.Throttled != 0 || m.Failed != 1 {
+		t.Errorf("unexpected metrics %s", m)
+	}
+}
+
+func TestRetryingProviderRateLimit(t *testing.T) {
+	tp := &testProvider{}
+	rp, slept := testRetryingProvider(tp, RetryingProviderInput{
+		RateLimits: map[string]RateLimit{ApiSubmitJob: {Rate: 2, Burst: 2}},
+	})
+	for i := 0; i < 4; i++ {
+		if err := rp.SubmitJob(&Job{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := []time.Duration{500 * time.Millisecond, 500 * time.Millisecond}
+	if fmt.Sprint(*slept) != fmt.Sprint(expected) {
+		t.Errorf("expected waits %v, got %v", expected, *slept)
+	}
+	if _, ok := rp.Metrics()[ApiStatus]; ok {
+		t.Error("expected no metrics for apis that were not called")
+	}
+}
+
+type pagedStatusProvider struct {
+	testProvider
+	calls int
+}
+
+func (p *pagedStatusProvider) Status(jobQueue string, query JobsSummaryQuery) error {
+	p.calls++
+	query.JobSummaryFunction([]JobSummary{{JobId: "1"}})
+	if p.calls == 1 {
+		return testApiError{"ThrottlingException"}
+	}
+	query.JobSummaryFunction([]JobSummary{{JobId: "2"}})
+	return nil
+}
+
+// pageRequestProvider makes each status page request through the query PageRequest
+type pageRequestProvider struct {
+	testProvider
+	requests map[int]int
+}
+
+func (p *pageRequestProvider) PagesSummaries() bool {
+	return true
+}
+
+func (p *pageRequestProvider) Status(jobQueue string, query JobsSummaryQuery) error {
+	for page := 1; page <= 3; page++ {
+		err := query.requestPage(func() error {
+			p.requests[page]++
+			if page == 2 && p.requests[page] == 1 {
+				return testApiError{"ThrottlingException"}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		query.JobSummaryFunction([]JobSummary{{JobId: fmt.Sprint(page)}})
+	}
+	return nil
+}
+
+func TestRetryingProviderStatusPages(t *testing.T) {
+	provider := &pageRequestProvider{requests: make(map[int]int)}
+	rp, _ := testRetryingProvider(provider, RetryingProviderInput{
+		RateLimits: map[string]RateLimit{ApiStatus: {Rate: 1}},
+	})
+	ids := []string{}
+	err := rp.Status("queue", JobsSummaryQuery{
+		JobSummaryFunction: func(summaries []JobSummary) {
+			for _, s := range summaries {
+				ids = append(ids, s.JobId)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(ids) != "[1 2 3]" || provider.requests[1] != 1 || provider.requests[2] != 2 || provider.requests[3] != 1 {
+		t.Errorf("expected only the failed page to be retried: %v %v", ids, provider.requests)
+	}
+	if m := rp.Metrics()[ApiStatus]; m.Calls != 4 || m.Retries != 1 {
+		t.Errorf("expected a rate limited call for each page request: %s", m)
+	}
+}
+
+func TestRetryingProviderStatusSkipsDeliveredPages(t *testing.T) {
+	rp, _ := testRetryingProvider(&pagedStatusProvider{}, RetryingProviderInput{})
+	ids := []string{}
+	err := rp.Status("queue", JobsSummaryQuery{
+		JobSummaryFunction: func(summaries []JobSummary) {
+			for _, s := range summaries {
+				ids = append(ids, s.JobId)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(ids) != "[1 2]" {
+		t.Errorf("expected each page once, got %v", ids)
+	}
+}
@@ -0,0 +1,194 @@
+package cloudcompute
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RunObserver receives progress callbacks while a compute runs.
+// Callbacks are made from the goroutine running the compute and should return quickly.
+type RunObserver interface {
+	//an event was read from the event generator and is about to be submitted
+	EventStarted(event Event)
+
+	//a payload was written for a manifest of the event
+	PayloadWritten(event Event, manifest ComputeManifest)
+
+	//a manifest job was submitted.  vendorID is the compute provider job identifier
+	ManifestSubmitted(event Event, manifest ComputeManifest, vendorID string)
+
+	//every manifest of the event was submitted
+	EventCompleted(event Event, duration time.Duration)
+
+	//the run stopped with an error.  The event is empty when the error is not from a single event
+	Error(event Event, err error)
+}
+
+// RunObserverFuncs is a RunObserver built from optional callback functions
+type RunObserverFuncs struct {
+	OnEventStarted      func(event Event)
+	OnPayloadWritten    func(event Event, manifest ComputeManifest)
+	OnManifestSubmitted func(event Event, manifest ComputeManifest, vendorID string)
+	OnEventCompleted    func(event Event, duration time.Duration)
+	OnError             func(event Event, err error)
+}
+
+func (of RunObserverFuncs) EventStarted(event Event) {
+	if of.OnEventStarted != nil {
+		of.OnEventStarted(event)
+	}
+}
+
+func (of RunObserverFuncs) PayloadWritten(event Event, manifest ComputeManifest) {
+	if of.OnPayloadWritten != nil {
+		of.OnPayloadWritten(event, manifest)
+	}
+}
+
+func (of RunObserverFuncs) ManifestSubmitted(event Event, manifest ComputeManifest, vendorID string) {
+	if of.OnManifestSubmitted != nil {
+		of.OnManifestSubmitted(event, manifest, vendorID)
+	}
+}
+
+func (of RunObserverFuncs) EventCompleted(event Event, duration time.Duration) {
+	if of.OnEventCompleted != nil {
+		of.OnEventCompleted(event, duration)
+	}
+}
+
+func (of RunObserverFuncs) Error(event Event, err error) {
+	if of.OnError != nil {
+		of.OnError(event, err)
+	}
+}
+
+// RunReport is the submission audit of a compute run
+type RunReport struct {
+	ComputeID uuid.UUID     `json:"compute_id"`
+	Start     time.Time     `json:"start"`
+	End       time.Time     `json:"end"`
+	Duration  time.Duration `json:"duration"`
+
+	//events read from the event generator
+	EventsStarted int `json:"events_started"`
+
+	//events with every manifest submitted
+	EventsSubmitted int `json:"events_submitted"`
+
+	JobsSubmitted   int `json:"jobs_submitted"`
+	PayloadsWritten int `json:"payloads_written"`
+
+	//submitted events in submission order
+	Events []EventSubmission `json:"events"`
+
+	//the error that stopped the run.  A run stops at the first failure.
+	Failures []RunFailure `json:"failures,omitempty"`
+
+	//manifest parameters that are not referenced by the command.
+	//each parameter of a manifest is reported for the first event it was found in.
+	UnusedParameters []UnusedParameter `json:"unused_parameters,omitempty"`
+}
+
+// The jobs submitted for an event
+type EventSubmission struct {
+	EventNumber int64               `json:"event_number"`
+	EventID     uuid.UUID           `json:"event_id"`
+	Duration    time.Duration       `json:"duration"`
+	Jobs        []SubmittedManifest `json:"jobs"`
+}
+
+// A manifest job submitted to the compute provider
+type SubmittedManifest struct {
+	ManifestID   string `json:"manifest_id"`
+	ManifestName string `json:"manifest_name"`
+
+	//compute provider job identifier
+	VendorID string `json:"vendor_id"`
+}
+
+// An error that stopped a run.  Jobs lists the manifests of the event that were
+// submitted before the error.  EventNumber and EventID are empty for errors that
+// are not from a single event (e.g. event generator errors).
+type RunFailure struct {
+	EventNumber int64               `json:"event_number"`
+	EventID     uuid.UUID           `json:"event_id"`
+	Error       string              `json:"error"`
+	Jobs        []SubmittedManifest `json:"jobs,omitempty"`
+}
+
+// A manifest parameter that is not referenced by the command run for the manifest
+type UnusedParameter struct {
+	EventNumber  int64  `json:"event_number"`
+	ManifestName string `json:"manifest_name"`
+	Parameter    string `json:"parameter"`
+}
+
+// records the progress of a run and forwards it to the observer
+type runRecorder struct {
+	report   RunReport
+	observer RunObserver
+
+	//manifest name and parameter of the reported unused parameters
+	unused map[[2]string]bool
+}
+
+func newRunRecorder(computeID uuid.UUID, observer RunObserver) *runRecorder {
+	if observer == nil {
+		observer = RunObserverFuncs{}
+	}
+	return &runRecorder{
+		report:   RunReport{ComputeID: computeID, Start: time.Now()},
+		observer: observer,
+		unused:   make(map[[2]string]bool),
+	}
+}
+
+func (rr *runRecorder) eventStarted(event Event) {
+	rr.report.EventsStarted++
+	rr.observer.EventStarted(event)
+}
+
+func (rr *runRecorder) payloadWritten(event Event, manifest ComputeManifest) {
+	rr.report.PayloadsWritten++
+	rr.observer.PayloadWritten(event, manifest)
+}
+
+func (rr *runRecorder) manifestSubmitted(event Event, submission *EventSubmission, manifest ComputeManifest, vendorID string) {
+	rr.report.JobsSubmitted++
+	submission.Jobs = append(submission.Jobs, SubmittedManifest{manifest.ManifestID, manifest.ManifestName, vendorID})
+	rr.observer.ManifestSubmitted(event, manifest, vendorID)
+}
+
+func (rr *runRecorder) eventCompleted(event Event, submission EventSubmission) {
+	rr.report.EventsSubmitted++
+	rr.report.Events = append(rr.report.Events, submission)
+	rr.observer.EventCompleted(event, submission.Duration)
+}
+
+func (rr *runRecorder) unusedParameters(unused []UnusedParameter) {
+	for _, u := range unused {
+		key := [2]string{u.ManifestName, u.Parameter}
+		if !rr.unused[key] {
+			rr.unused[key] = true
+			rr.report.UnusedParameters = append(rr.report.UnusedParameters, u)
+		}
+	}
+}
+
+func (rr *runRecorder) failed(event Event, submission EventSubmission, err error) {
+	rr.report.Failures = append(rr.report.Failures, RunFailure{
+		EventNumber: submission.EventNumber,
+		EventID:     submission.EventID,
+		Error:       err.Error(),
+		Jobs:        submission.Jobs,
+	})
+	rr.observer.Error(event, err)
+}
+
+func (rr *runRecorder) finish() RunReport {
+	rr.report.End = time.Now()
+	rr.report.Duration = rr.report.End.Sub(rr.report.Start)
+	return rr.report
+}
@@ -0,0 +1,98 @@
+package cloudcompute
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRunReport(t *testing.T) {
+	events := testEvents(3)
+	for i := range events {
+		events[i].Manifests = append(events[i].Manifests, ComputeManifest{
+			ManifestName:     "hms",
+			ManifestID:       "hms-id",
+			PluginDefinition: "hms",
+			Dependencies:     []JobDependency{{JobId: "ras-id"}},
+		})
+	}
+	provider := &testProvider{}
+	calls := []string{}
+	cc := CloudCompute{
+		ID:              uuid.New(),
+		Events:          NewEventList(events),
+		ComputeProvider: provider,
+		Observer: RunObserverFuncs{
+			OnEventStarted: func(event Event) {
+				calls = append(calls, fmt.Sprintf("start %d", event.EventNumber))
+			},
+			OnManifestSubmitted: func(event Event, manifest ComputeManifest, vendorID string) {
+				calls = append(calls, fmt.Sprintf("submit %d %s %s", event.EventNumber, manifest.ManifestName, vendorID))
+			},
+			OnEventCompleted: func(event Event, duration time.Duration) {
+				calls = append(calls, fmt.Sprintf("complete %d", event.EventNumber))
+			},
+		},
+	}
+	report, err := cc.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.ComputeID != cc.ID || report.EventsStarted != 3 || report.EventsSubmitted != 3 || report.JobsSubmitted != 6 || len(report.Failures) != 0 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if report.End.Before(report.Start) || report.Duration < 0 {
+		t.Errorf("invalid report times %v %v", report.Start, report.End)
+	}
+	second := report.Events[1]
+	if second.EventNumber != 2 || second.EventID != events[1].ID || len(second.Jobs) != 2 || second.Jobs[1].VendorID != "job-4" {
+		t.Errorf("unexpected event submission %+v", second)
+	}
+	expected := "start 1,submit 1 ras job-1,submit 1 hms job-2,complete 1"
+	if got := strings.Join(calls[:4], ","); got != expected {
+		t.Errorf("expected callbacks %s, got %s", expected, got)
+	}
+}
+
+func TestRunReportFailure(t *testing.T) {
+	events := testEvents(3)
+	for i := range events {
+		events[i].Manifests = append(events[i].Manifests, ComputeManifest{ManifestName: "hms", ManifestID: "hms-id", PluginDefinition: "hms"})
+	}
+	provider := &testProvider{
+		submitErr: func(job *Job) error {
+			if jobEventNumber(*job) == "2" && job.JobDefinition == "hms" {
+				return errors.New("submit failed")
+			}
+			return nil
+		},
+	}
+	var observed error
+	cc := CloudCompute{
+		ID:              uuid.New(),
+		Events:          NewEventList(events),
+		ComputeProvider: provider,
+		Observer: RunObserverFuncs{
+			OnError: func(event Event, err error) {
+				if event.EventNumber == 2 {
+					observed = err
+				}
+			},
+		},
+	}
+	report, err := cc.Run()
+	if err == nil || observed != err {
+		t.Fatalf("expected the observer to receive the run error: %v %v", err, observed)
+	}
+	if report.EventsStarted != 2 || report.EventsSubmitted != 1 || report.JobsSubmitted != 3 || len(report.Failures) != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	failure := report.Failures[0]
+	if failure.EventNumber != 2 || failure.Error != "submit failed" || len(failure.Jobs) != 1 || failure.Jobs[0].ManifestName != "ras" {
+		t.Errorf("unexpected failure %+v", failure)
+	}
+}
@@ -0,0 +1,382 @@
+package cloudcompute
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/google/uuid"
+)
+
+type DistributionType string
+
+const (
+	DistributionUniform    DistributionType = "uniform"
+	DistributionNormal     DistributionType = "normal"
+	DistributionLognormal  DistributionType = "lognormal"
+	DistributionTriangular DistributionType = "triangular"
+	DistributionDiscrete   DistributionType = "discrete"
+)
+
+type SamplingMethod string
+
+const (
+	SamplingLatinHypercube SamplingMethod = "latin-hypercube"
+	SamplingSobol          SamplingMethod = "sobol"
+)
+
+// Distribution of a sampled parameter.
+//   - uniform: Min and Max
+//   - normal: Mean and StdDev
+//   - lognormal: Mean and StdDev of the logarithm of the parameter
+//   - triangular: Min, Mode and Max
+//   - discrete: Values with optional relative Weights (default is equal weights)
+type Distribution struct {
+	Name    string           `json:"name" yaml:"name"`
+	Type    DistributionType `json:"type" yaml:"type"`
+	Min     float64          `json:"min,omitempty" yaml:"min"`
+	Max     float64          `json:"max,omitempty" yaml:"max"`
+	Mode    float64          `json:"mode,omitempty" yaml:"mode"`
+	Mean    float64          `json:"mean,omitempty" yaml:"mean"`
+	StdDev  float64          `json:"std_dev,omitempty" yaml:"std_dev"`
+	Values  []string         `json:"values,omitempty" yaml:"values"`
+	Weights []float64        `json:"weights,omitempty" yaml:"weights"`
+}
+
+func (d Distribution) Validate() error {
+	switch d.Type {
+	case DistributionUniform:
+		if d.Max <= d.Min {
+			return fmt.Errorf("uniform distribution %s max must be greater than min", d.Name)
+		}
+	case DistributionNormal, DistributionLognormal:
+		if d.StdDev <= 0 {
+			return fmt.Errorf("%s distribution %s standard deviation must be positive", d.Type, d.Name)
+		}
+	case DistributionTriangular:
+		if d.Max <= d.Min || d.Mode < d.Min || d.Mode > d.Max {
+			return fmt.Errorf("triangular distribution %s requires min <= mode <= max and min < max", d.Name)
+		}
+	case DistributionDiscrete:
+		if len(d.Values) == 0 {
+			return fmt.Errorf("discrete distribution %s has no values", d.Name)
+		}
+		if len(d.Weights) > 0 {
+			if len(d.Weights) != len(d.Values) {
+				return fmt.Errorf("discrete distribution %s has %d weights for %d values", d.Name, len(d.Weights), len(d.Values))
+			}
+			total := 0.0
+			for _, w := range d.Weights {
+				if w < 0 {
+					return fmt.Errorf("discrete distribution %s has a negative weight", d.Name)
+				}
+				total += w
+			}
+			if total <= 0 {
+				return fmt.Errorf("discrete distribution %s weights sum to zero", d.Name)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported distribution type %q for %s", d.Type, d.Name)
+	}
+	return nil
+}
+
+// Returns the value of the distribution at cumulative probability p.
+// Continuous distributions return a float64 and discrete distributions return the selected string value.
+func (d Distribution) Quantile(p float64) interface{} {
+	//keep the tails of unbounded distributions finite
+	p = math.Min(math.Max(p, 1e-12), 1-1e-12)
+	switch d.Type {
+	case DistributionUniform:
+		return d.Min + p*(d.Max-d.Min)
+	case DistributionNormal:
+		return d.Mean + d.StdDev*math.Sqrt2*math.Erfinv(2*p-1)
+	case DistributionLognormal:
+		return math.Exp(d.Mean + d.StdDev*math.Sqrt2*math.Erfinv(2*p-1))
+	case DistributionTriangular:
+		width := d.Max - d.Min
+		if p < (d.Mode-d.Min)/width {
+			return d.Min + math.Sqrt(p*width*(d.Mode-d.Min))
+		}
+		return d.Max - math.Sqrt((1-p)*width*(d.Max-d.Mode))
+	case DistributionDiscrete:
+		if len(d.Weights) == 0 {
+			return d.Values[int(p*float64(len(d.Values)))]
+		}
+		total := 0.0
+		for _, w := range d.Weights {
+			total += w
+		}
+		cumulative := 0.0
+		for i, w := range d.Weights {
+			cumulative += w
+			if p*total < cumulative {
+				return d.Values[i]
+			}
+		}
+		return d.Values[len(d.Values)-1]
+	}
+	return nil
+}
+
+// Inputs for a sampling event generator
+type SamplingEventGeneratorInput struct {
+	//base event run for every sample
+	Event Event
+
+	Method        SamplingMethod
+	Distributions []Distribution
+
+	//number of events
+	Samples int64
+
+	//random seed.  the same seed produces the same samples
+	Seed int64
+
+	//event number of the first sample
+	StartEventNumber int64
+}
+
+// SamplingEventGenerator is an EventGenerator that runs the base event for a space filling
+// design sampled from the parameter distributions using Latin hypercube or Sobol sampling.
+// Each event is assigned a unique identifier and the sampled values are added to the
+// environment and payload attributes of every manifest and to the event template variables.
+type SamplingEventGenerator struct {
+	event         Event
+	names         []string
+	distributions []Distribution
+	samples       int64
+	start         int64
+	position      int64
+	sampler       unitSampler
+	newSampler    func() unitSampler
+}
+
+// Creates a Latin hypercube or Sobol sampling event generator
+func NewSamplingEventGenerator(input SamplingEventGeneratorInput) (*SamplingEventGenerator, error) {
+	err := prepareEvent(&input.Event)
+	if err != nil {
+		return nil, err
+	}
+	if input.Samples <= 0 {
+		return nil, fmt.Errorf("Invalid sampling: samples must be positive")
+	}
+	if len(input.Distributions) == 0 {
+		return nil, fmt.Errorf("Invalid sampling: no distributions")
+	}
+	names := make([]string, len(input.Distributions))
+	seen := make(map[string]bool)
+	for i, d := range input.Distributions {
+		if d.Name == "" || seen[d.Name] {
+			return nil, fmt.Errorf("Invalid sampling: missing or duplicate distribution name %q", d.Name)
+		}
+		seen[d.Name] = true
+		err = d.Validate()
+		if err != nil {
+			return nil, fmt.Errorf("Invalid sampling: %s", err)
+		}
+		names[i] = d.Name
+	}
+	dims := len(input.Distributions)
+	var newSampler func() unitSampler
+	switch input.Method {
+	case SamplingLatinHypercube, "":
+		newSampler = func() unitSampler {
+			return newLatinHypercube(dims, input.Samples, input.Seed)
+		}
+	case SamplingSobol:
+		_, err = newSobolSampler(dims, input.Seed)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid sampling: %s", err)
+		}
+		newSampler = func() unitSampler {
+			ss, _ := newSobolSampler(dims, input.Seed)
+			return ss
+		}
+	default:
+		return nil, fmt.Errorf("Invalid sampling: unsupported sampling method %q", input.Method)
+	}
+	return &SamplingEventGenerator{
+		event:         input.Event,
+		names:         names,
+		distributions: input.Distributions,
+		samples:       input.Samples,
+		start:         input.StartEventNumber,
+		sampler:       newSampler(),
+		newSampler:    newSampler,
+	}, nil
+}
+
+func (seg *SamplingEventGenerator) HasNextEvent() bool {
+	return seg.position < seg.samples
+}
+
+func (seg *SamplingEventGenerator) NextEvent() Event {
+	u := seg.sampler.next(seg.position)
+	values := make([]interface{}, len(u))
+	for i, p := range u {
+		values[i] = seg.distributions[i].Quantile(p)
+	}
+	event := seg.event.withVariables(seg.names, values)
+	event.ID = uuid.New()
+	event.EventNumber = seg.start + seg.position
+	seg.position++
+	return event
+}
+
+// unitSampler produces points in the unit hypercube.
+// Points are requested in order starting at index 0.
+type unitSampler interface {
+	next(index int64) []float64
+}
+
+// Latin hypercube design.  Each dimension is divided into one stratum per sample
+// and every stratum is sampled exactly once at a random location within the stratum.
+type latinHypercube struct {
+	strata  [][]int
+	samples int64
+	rng     *rand.Rand
+}
+
+func newLatinHypercube(dims int, samples int64, seed int64) *latinHypercube {
+	rng := rand.New(rand.NewSource(seed))
+	strata := make([][]int, dims)
+	for i := range strata {
+		strata[i] = rng.Perm(int(samples))
+	}
+	return &latinHypercube{strata, samples, rng}
+}
+
+func (lh *latinHypercube) next(index int64) []float64 {
+	u := make([]float64, len(lh.strata))
+	for i, s := range lh.strata {
+		u[i] = (float64(s[index]) + lh.rng.Float64()) / float64(lh.samples)
+	}
+	return u
+}
+
+// Sobol sequence using the Joe and Kuo direction numbers (new-joe-kuo-6.21201).
+// The first point of the sequence (the origin) is skipped and the points are
+// randomized with a digital shift derived from the seed.
+type sobolSampler struct {
+	directions [][]uint32
+	x          []uint32
+	shift      []uint32
+	count      uint64
+}
+
+type sobolPolynomial struct {
+	s int
+	a uint32
+	m []uint32
+}
+
+// direction numbers for dimensions 2 and up
+var sobolPolynomials = []sobolPolynomial{
+	{1, 0, []uint32{1}},
+	{2, 1, []uint32{1, 3}},
+	{3, 1, []uint32{1, 3, 1}},
+	{3, 2, []uint32{1, 1, 1}},
+	{4, 1, []uint32{1, 1, 3, 3}},
+	{4, 4, []uint32{1, 3, 5, 13}},
+	{5, 2, []uint32{1, 1, 5, 5, 17}},
+	{5, 4, []uint32{1, 1, 5, 5, 5}},
+	{5, 7, []uint32{1, 1, 7, 11, 19}},
+	{5, 11, []uint32{1, 1, 5, 1, 1}},
+	{5, 13, []uint32{1, 1, 1, 3, 11}},
+	{5, 14, []uint32{1, 3, 5, 5, 31}},
+	{6, 1, []uint32{1, 3, 3, 9, 7, 49}},
+	{6, 13, []uint32{1, 1, 1, 15, 21, 21}},
+	{6, 16, []uint32{1, 3, 1, 13, 27, 49}},
+	{6, 19, []uint32{1, 1, 1, 15, 7, 5}},
+	{6, 22, []uint32{1, 3, 1, 15, 13, 25}},
+	{6, 25, []uint32{1, 1, 5, 5, 19, 61}},
+	{7, 1, []uint32{1, 3, 7, 11, 23, 15, 103}},
+	{7, 4, []uint32{1, 3, 7, 13, 13, 15, 69}},
+}
+
+const sobolBits = 32
+
+func newSobolSampler(dims int, seed int64) (*sobolSampler, error) {
+	if dims > len(sobolPolynomials)+1 {
+		return nil, fmt.Errorf("sobol sampling supports at most %d dimensions", len(sobolPolynomials)+1)
+	}
+	directions := make([][]uint32, dims)
+	for d := range directions {
+		v := make([]uint32, sobolBits)
+		if d == 0 {
+			for k := range v {
+				v[k] = 1 << (sobolBits - 1 - k)
+			}
+		} else {
+			p := sobolPolynomials[d-1]
+			for k := 0; k < sobolBits; k++ {
+				if k < p.s {
+					v[k] = p.m[k] << (sobolBits - 1 - k)
+					continue
+				}
+				v[k] = v[k-p.s] ^ (v[k-p.s] >> p.s)
+				for j := 1; j < p.s; j++ {
+					if (p.a>>(p.s-1-j))&1 == 1 {
+						v[k] ^= v[k-j]
+					}
+				}
+			}
+		}
+		directions[d] = v
+	}
+	rng := rand.New(rand.NewSource(seed))
+	shift := make([]uint32, dims)
+	for i := range shift {
+		shift[i] = rng.Uint32()
+	}
+	return &sobolSampler{
+		directions: directions,
+		x:          make([]uint32, dims),
+		shift:      shift,
+	}, nil
+}
+
+// advances to the next point of the unshifted sequence using the gray code ordering
+func (ss *sobolSampler) advance() {
+	c := 0
+	for i := ss.count; i&1 == 1; i >>= 1 {
+		c++
+	}
+	for d := range ss.x {
+		ss.x[d] ^= ss.directions[d][c]
+	}
+	ss.count++
+}
+
+func (ss *sobolSampler) next(index int64) []float64 {
+	ss.advance()
+	u := make([]float64, len(ss.x))
+	for d, x := range ss.x {
+		u[d] = (float64(x^ss.shift[d]) + 0.5) / (1 << sobolBits)
+	}
+	return u
+}
+
+func (seg *SamplingEventGenerator) Checkpoint() ([]byte, error) {
+	return encodeCheckpoint(generatorCheckpoint{Generator: "sampling", Position: seg.position})
+}
+
+// Restores the generator position.  The random number state is restored by
+// regenerating the samples preceding the position from the seed.
+func (seg *SamplingEventGenerator) Restore(checkpoint []byte) error {
+	cp, err := decodeCheckpoint("sampling", checkpoint)
+	if err != nil {
+		return err
+	}
+	if cp.Position < 0 || cp.Position > seg.samples {
+		return fmt.Errorf("Invalid checkpoint: position %d is outside of the %d samples", cp.Position, seg.samples)
+	}
+	seg.sampler = seg.newSampler()
+	for i := int64(0); i < cp.Position; i++ {
+		seg.sampler.next(i)
+	}
+	seg.position = cp.Position
+	return nil
+}
@@ -0,0 +1,114 @@
+package cloudcompute
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSobolSequence(t *testing.T) {
+	ss, err := newSobolSampler(3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]float64{
+		{0.5, 0.5, 0.5},
+		{0.75, 0.25, 0.25},
+		{0.25, 0.75, 0.75},
+		{0.375, 0.375, 0.625},
+		{0.875, 0.875, 0.125},
+	}
+	for i, point := range expected {
+		ss.advance()
+		for d, v := range point {
+			if got := float64(ss.x[d]) / (1 << sobolBits); got != v {
+				t.Errorf("point %d dimension %d: expected %v, got %v", i+1, d, v, got)
+			}
+		}
+	}
+	_, err = newSobolSampler(len(sobolPolynomials)+2, 0)
+	if err == nil {
+		t.Error("expected an error for too many dimensions")
+	}
+}
+
+func TestLatinHypercubeStrata(t *testing.T) {
+	samples := int64(50)
+	lh := newLatinHypercube(3, samples, 7)
+	hits := make([]map[int]bool, 3)
+	for d := range hits {
+		hits[d] = make(map[int]bool)
+	}
+	for i := int64(0); i < samples; i++ {
+		for d, u := range lh.next(i) {
+			hits[d][int(u*float64(samples))] = true
+		}
+	}
+	for d, h := range hits {
+		if int64(len(h)) != samples {
+			t.Errorf("dimension %d sampled %d of %d strata", d, len(h), samples)
+		}
+	}
+}
+
+func TestDistributionQuantiles(t *testing.T) {
+	tests := []struct {
+		d        Distribution
+		p        float64
+		expected float64
+	}{
+		{Distribution{Type: DistributionUniform, Min: 2, Max: 4}, 0.25, 2.5},
+		{Distribution{Type: DistributionNormal, Mean: 10, StdDev: 2}, 0.5, 10},
+		{Distribution{Type: DistributionNormal, Mean: 0, StdDev: 1}, 0.975, 1.959964},
+		{Distribution{Type: DistributionLognormal, Mean: 0, StdDev: 1}, 0.5, 1},
+		{Distribution{Type: DistributionTriangular, Min: 0, Mode: 1, Max: 2}, 0.5, 1},
+		{Distribution{Type: DistributionTriangular, Min: 0, Mode: 0, Max: 1}, 0.75, 0.5},
+	}
+	for _, test := range tests {
+		got := test.d.Quantile(test.p).(float64)
+		if math.Abs(got-test.expected) > 1e-6 {
+			t.Errorf("%s quantile %v: expected %v, got %v", test.d.Type, test.p, test.expected, got)
+		}
+	}
+	discrete := Distribution{Type: DistributionDiscrete, Values: []string{"a", "b", "c"}, Weights: []float64{1, 2, 1}}
+	if discrete.Quantile(0.2) != "a" || discrete.Quantile(0.5) != "b" || discrete.Quantile(0.8) != "c" {
+		t.Error("unexpected discrete quantiles")
+	}
+}
+
+func TestSamplingEventGeneratorIsReproducible(t *testing.T) {
+	input := SamplingEventGeneratorInput{
+		Event:   sweepEvent(),
+		Method:  SamplingSobol,
+		Samples: 8,
+		Seed:    42,
+		Distributions: []Distribution{
+			{Name: "roughness", Type: DistributionUniform, Min: 0.02, Max: 0.06},
+			{Name: "scenario", Type: DistributionDiscrete, Values: []string{"base", "future"}},
+		},
+	}
+	for _, method := range []SamplingMethod{SamplingSobol, SamplingLatinHypercube} {
+		input.Method = method
+		first, err := NewSamplingEventGenerator(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, _ := NewSamplingEventGenerator(input)
+		count := 0
+		for first.HasNextEvent() {
+			a := first.NextEvent()
+			b := second.NextEvent()
+			count++
+			attrs := a.Manifests[0].Inputs.PayloadAttributes
+			r, ok := attrs["roughness"].(float64)
+			if !ok || r < 0.02 || r > 0.06 {
+				t.Errorf("%s: unexpected roughness %v", method, attrs["roughness"])
+			}
+			if a.Vars["roughness"] != b.Vars["roughness"] || a.Vars["scenario"] != b.Vars["scenario"] {
+				t.Errorf("%s: samples differ for the same seed: %v %v", method, a.Vars, b.Vars)
+			}
+		}
+		if count != 8 {
+			t.Errorf("%s: expected 8 events, got %d", method, count)
+		}
+	}
+}
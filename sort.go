@@ -2,9 +2,14 @@ package cloudcompute
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 )
 
 // Interface for supporting Topological sort in Manifests (or other structs that would use a toposort)
+//
+// Deprecated: event manifests are ordered with Event.OrderManifests, which keeps
+// independent manifests in their original order.
 type TopoSortable[T comparable] interface {
 	Node() T
 	Deps() []T
@@ -25,13 +30,122 @@ func (m ComputeManifest) Deps() []string {
 }
 
 // Topological Sort function for an Event
-// returns an ordered list of manifest IDs
+// returns an ordered list of manifest IDs that includes manifests without dependencies
 func (e *Event) TopoSort() ([]string, error) {
-	digraph := depsToGraph(e.toTopoSortable())
-	if len(digraph) == 0 {
-		return nil, errors.New("No dependencies beween manifests provided")
+	ordered, err := e.orderedManifests()
+	if err != nil {
+		return nil, err
 	}
-	return TopologicalSort(digraph)
+	ids := make([]string, len(ordered))
+	for i, m := range ordered {
+		ids[i] = m.ManifestID
+	}
+	return ids, nil
+}
+
+// Orders the event manifests so every manifest follows the manifests it depends on.
+// Independent manifests keep their relative order.  Returns an error if a dependency
+// is not in the event or the dependencies form a cycle.
+func (e *Event) OrderManifests() error {
+	if e.manifestsOrdered() {
+		return nil
+	}
+	ordered, err := e.orderedManifests()
+	if err != nil {
+		return err
+	}
+	e.Manifests = ordered
+	return nil
+}
+
+// Determines if every manifest follows its dependencies
+func (e *Event) manifestsOrdered() bool {
+	seen := make(map[string]bool, len(e.Manifests))
+	for _, m := range e.Manifests {
+		for _, d := range m.Dependencies {
+			if !seen[d.JobId] {
+				return false
+			}
+		}
+		seen[m.ManifestID] = true
+	}
+	return true
+}
+
+// depth first topological ordering visiting the manifests in their original order
+func (e *Event) orderedManifests() ([]ComputeManifest, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	index := make(map[string]int, len(e.Manifests))
+	for i, m := range e.Manifests {
+		index[m.ManifestID] = i
+	}
+	state := make([]int, len(e.Manifests))
+	ordered := make([]ComputeManifest, 0, len(e.Manifests))
+	path := []int{}
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		m := e.Manifests[i]
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			start := indexOf(path, i)
+			if start < 0 {
+				return fmt.Errorf("Invalid DAG for event %d: manifest %s is not on the dependency path", e.EventNumber, manifestLabel(m))
+			}
+			cycle := []string{}
+			for _, j := range append(path[start:], i) {
+				cycle = append(cycle, manifestLabel(e.Manifests[j]))
+			}
+			return fmt.Errorf("Invalid DAG for event %d: manifests %s form a cycle", e.EventNumber, strings.Join(cycle, " -> "))
+		}
+		state[i] = visiting
+		path = append(path, i)
+		for _, d := range m.Dependencies {
+			j, ok := index[d.JobId]
+			if !ok {
+				return fmt.Errorf("Invalid DAG for event %d: manifest %s depends on unknown manifest %s", e.EventNumber, manifestLabel(m), d.JobId)
+			}
+			err := visit(j)
+			if err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+		ordered = append(ordered, m)
+		return nil
+	}
+
+	for i := range e.Manifests {
+		err := visit(i)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+func manifestLabel(m ComputeManifest) string {
+	if m.ManifestName != "" {
+		return m.ManifestName
+	}
+	return m.ManifestID
+}
+
+// Returns the index of val in vals or -1 if vals does not contain val
+func indexOf[T comparable](vals []T, val T) int {
+	for i, v := range vals {
+		if v == val {
+			return i
+		}
+	}
+	return -1
 }
 
 func (e *Event) toTopoSortable() []TopoSortable[string] {
@@ -2,6 +2,7 @@ package cloudcompute
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -83,19 +84,19 @@ func TestTopoSort(t *testing.T) {
 		},
 		{
 			ManifestID:   "2",
-			Dependencies: []JobDependency{{"1"}},
+			Dependencies: []JobDependency{{JobId: "1"}},
 		},
 		{
 			ManifestID:   "3",
-			Dependencies: []JobDependency{{"2"}},
+			Dependencies: []JobDependency{{JobId: "2"}},
 		},
 		{
 			ManifestID:   "4",
-			Dependencies: []JobDependency{{"1"}, {"3"}},
+			Dependencies: []JobDependency{{JobId: "1"}, {JobId: "3"}},
 		},
 		{
 			ManifestID:   "5",
-			Dependencies: []JobDependency{{"2"}, {"3"}},
+			Dependencies: []JobDependency{{JobId: "2"}, {JobId: "3"}},
 		},
 	}
 	event := Event{
@@ -107,3 +108,45 @@ func TestTopoSort(t *testing.T) {
 	}
 	fmt.Println(ordered)
 }
+
+func TestOrderManifests(t *testing.T) {
+	event := Event{
+		Manifests: []ComputeManifest{
+			{ManifestID: "timing", Dependencies: []JobDependency{{JobId: "ras"}, {JobId: "hms"}}},
+			{ManifestID: "report"},
+			{ManifestID: "ras", Dependencies: []JobDependency{{JobId: "hms"}}},
+			{ManifestID: "hms"},
+		},
+	}
+	err := event.OrderManifests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, _ := event.TopoSort()
+	if strings.Join(ids, ",") != "hms,ras,timing,report" {
+		t.Errorf("unexpected manifest order: %v", ids)
+	}
+
+	//hms -> ras -> timing -> hms
+	event.Manifests[0].Dependencies = []JobDependency{{JobId: "timing"}}
+	err = event.OrderManifests()
+	if err == nil || !strings.Contains(err.Error(), "hms -> timing -> ras -> hms") {
+		t.Errorf("expected a cycle error: %v", err)
+	}
+	if indexOf([]int{1, 2}, 3) != -1 {
+		t.Error("expected a missing value to have no index")
+	}
+}
+
+func TestArrayEventGeneratorRejectsInvalidDag(t *testing.T) {
+	event := Event{
+		Manifests: []ComputeManifest{
+			{ManifestName: "a", Dependencies: []JobDependency{{ManifestName: "b"}}},
+			{ManifestName: "b", Dependencies: []JobDependency{{ManifestName: "a"}}},
+		},
+	}
+	_, err := NewArrayEventGenerator(event, 1, 10)
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected a cycle error: %v", err)
+	}
+}
@@ -0,0 +1,286 @@
+package cloudcompute
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	. "github.com/usace/cc-go-sdk"
+
+	"github.com/google/uuid"
+)
+
+type SweepMode string
+
+const (
+	//every combination of the axis values
+	SweepCartesian SweepMode = "cartesian"
+
+	//the nth value of every axis.  all axes must have the same number of values
+	SweepZip SweepMode = "zip"
+
+	//an explicit list of parameter sets
+	SweepList SweepMode = "list"
+)
+
+// SweepAxis is a named parameter and the values it is swept over
+type SweepAxis struct {
+	Name   string   `json:"name" yaml:"name"`
+	Values []string `json:"values" yaml:"values"`
+}
+
+// Inputs for a parameter sweep.  Cartesian and zip sweeps use the Axes, list sweeps use the Sets.
+type SweepEventGeneratorInput struct {
+	//base event run for every parameter set
+	Event Event
+
+	//default is SweepCartesian
+	Mode SweepMode
+
+	//parameter axes for cartesian and zip sweeps
+	Axes []SweepAxis
+
+	//parameter sets for list sweeps.  every set must have the same parameter names
+	Sets []map[string]string
+
+	//event number of the first parameter set
+	StartEventNumber int64
+}
+
+// SweepEventGenerator is an EventGenerator that runs the base event for each parameter set of a sweep.
+// Each event is assigned a unique identifier and the parameter values are added to the
+// environment and payload attributes of every manifest and to the event template variables.
+type SweepEventGenerator struct {
+	event    Event
+	names    []string
+	values   func(i int64) []string
+	count    int64
+	start    int64
+	position int64
+}
+
+// Creates a sweep event generator.  Returns an error for an empty or inconsistent sweep.
+func NewSweepEventGenerator(input SweepEventGeneratorInput) (*SweepEventGenerator, error) {
+	err := prepareEvent(&input.Event)
+	if err != nil {
+		return nil, err
+	}
+	seg := SweepEventGenerator{
+		event: input.Event,
+		start: input.StartEventNumber,
+	}
+	switch input.Mode {
+	case SweepCartesian, "":
+		err = seg.cartesian(input.Axes)
+	case SweepZip:
+		err = seg.zip(input.Axes)
+	case SweepList:
+		err = seg.list(input.Sets)
+	default:
+		err = fmt.Errorf("unsupported sweep mode %q", input.Mode)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid sweep: %s", err)
+	}
+	return &seg, nil
+}
+
+func (seg *SweepEventGenerator) HasNextEvent() bool {
+	return seg.position < seg.count
+}
+
+func (seg *SweepEventGenerator) NextEvent() Event {
+	if !seg.HasNextEvent() {
+		return Event{}
+	}
+	svals := seg.values(seg.position)
+	values := make([]interface{}, len(svals))
+	for i, v := range svals {
+		values[i] = v
+	}
+	event := seg.event.withVariables(seg.names, values)
+	event.ID = uuid.New()
+	event.EventNumber = seg.start + seg.position
+	seg.position++
+	return event
+}
+
+// Number of events in the sweep
+func (seg *SweepEventGenerator) Count() int64 {
+	return seg.count
+}
+
+// Writes the event number and parameter values of every event in the sweep as CSV
+func (seg *SweepEventGenerator) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write(append([]string{"event_number"}, seg.names...))
+	if err != nil {
+		return err
+	}
+	for i := int64(0); i < seg.count; i++ {
+		err = cw.Write(append([]string{strconv.FormatInt(seg.start+i, 10)}, seg.values(i)...))
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (seg *SweepEventGenerator) cartesian(axes []SweepAxis) error {
+	err := seg.setAxes(axes)
+	if err != nil {
+		return err
+	}
+	seg.count = 1
+	for _, a := range axes {
+		n := int64(len(a.Values))
+		if n > 0 && seg.count > math.MaxInt64/n {
+			return fmt.Errorf("the cartesian product of the axes has more than %d events", int64(math.MaxInt64))
+		}
+		seg.count *= n
+	}
+	//the last axis varies fastest
+	seg.values = func(i int64) []string {
+		vals := make([]string, len(axes))
+		for j := len(axes) - 1; j >= 0; j-- {
+			n := int64(len(axes[j].Values))
+			vals[j] = axes[j].Values[i%n]
+			i /= n
+		}
+		return vals
+	}
+	return nil
+}
+
+func (seg *SweepEventGenerator) zip(axes []SweepAxis) error {
+	err := seg.setAxes(axes)
+	if err != nil {
+		return err
+	}
+	seg.count = int64(len(axes[0].Values))
+	for _, a := range axes {
+		if int64(len(a.Values)) != seg.count {
+			return fmt.Errorf("zipped axis %s has %d values, expected %d", a.Name, len(a.Values), seg.count)
+		}
+	}
+	seg.values = func(i int64) []string {
+		vals := make([]string, len(axes))
+		for j, a := range axes {
+			vals[j] = a.Values[i]
+		}
+		return vals
+	}
+	return nil
+}
+
+func (seg *SweepEventGenerator) list(sets []map[string]string) error {
+	if len(sets) == 0 {
+		return fmt.Errorf("no parameter sets")
+	}
+	for name := range sets[0] {
+		seg.names = append(seg.names, name)
+	}
+	sort.Strings(seg.names)
+	rows := make([][]string, len(sets))
+	for i, set := range sets {
+		if len(set) != len(seg.names) {
+			return fmt.Errorf("parameter set %d does not match the parameters %v", i, seg.names)
+		}
+		rows[i] = make([]string, len(seg.names))
+		for j, name := range seg.names {
+			val, ok := set[name]
+			if !ok {
+				return fmt.Errorf("parameter set %d is missing parameter %s", i, name)
+			}
+			rows[i][j] = val
+		}
+	}
+	seg.count = int64(len(rows))
+	seg.values = func(i int64) []string {
+		return rows[i]
+	}
+	return nil
+}
+
+func (seg *SweepEventGenerator) setAxes(axes []SweepAxis) error {
+	if len(axes) == 0 {
+		return fmt.Errorf("no axes")
+	}
+	names := make(map[string]bool)
+	for _, a := range axes {
+		if a.Name == "" {
+			return fmt.Errorf("axis is missing a name")
+		}
+		if names[a.Name] {
+			return fmt.Errorf("duplicate axis %s", a.Name)
+		}
+		if len(a.Values) == 0 {
+			return fmt.Errorf("axis %s has no values", a.Name)
+		}
+		names[a.Name] = true
+		seg.names = append(seg.names, a.Name)
+	}
+	return nil
+}
+
+// Returns a copy of the event with the variables added to the event template variables
+// and to the environment and payload attributes of every manifest.
+// Each manifest writes its own payload since the payload attributes are specific to the event.
+func (e Event) withVariables(names []string, values []interface{}) Event {
+	vars := make(map[string]string, len(e.Vars)+len(names))
+	for k, v := range e.Vars {
+		vars[k] = v
+	}
+	svals := make([]string, len(values))
+	replaced := make(map[string]bool, len(names))
+	for i, name := range names {
+		svals[i] = fmt.Sprint(values[i])
+		vars[name] = svals[i]
+		replaced[name] = true
+	}
+	e.Vars = vars
+
+	manifests := make([]ComputeManifest, len(e.Manifests))
+	for i, m := range e.Manifests {
+		env := make(KeyValuePairs, 0, len(m.Inputs.Environment)+len(names))
+		for _, kvp := range m.Inputs.Environment {
+			if !replaced[kvp.Name] {
+				env = append(env, kvp)
+			}
+		}
+		attrs := make(PayloadAttributes, len(m.Inputs.PayloadAttributes)+len(names))
+		for k, v := range m.Inputs.PayloadAttributes {
+			attrs[k] = v
+		}
+		for j, name := range names {
+			env = append(env, KeyValuePair{name, svals[j]})
+			attrs[name] = values[j]
+		}
+		m.Inputs.Environment = env
+		m.Inputs.PayloadAttributes = attrs
+		m.detachPayload()
+		manifests[i] = m
+	}
+	e.Manifests = manifests
+	return e
+}
+
+func (seg *SweepEventGenerator) Checkpoint() ([]byte, error) {
+	return encodeCheckpoint(generatorCheckpoint{Generator: "sweep", Position: seg.position})
+}
+
+func (seg *SweepEventGenerator) Restore(checkpoint []byte) error {
+	cp, err := decodeCheckpoint("sweep", checkpoint)
+	if err != nil {
+		return err
+	}
+	if cp.Position < 0 || cp.Position > seg.count {
+		return fmt.Errorf("Invalid checkpoint: position %d is outside of the sweep of %d events", cp.Position, seg.count)
+	}
+	seg.position = cp.Position
+	return nil
+}
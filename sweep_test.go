@@ -0,0 +1,113 @@
+package cloudcompute
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	. "github.com/usace/cc-go-sdk"
+)
+
+func sweepEvent() Event {
+	return Event{
+		Manifests: []ComputeManifest{
+			{
+				ManifestName: "ras",
+				Inputs: PluginInputs{
+					Environment:       KeyValuePairs{{"roughness", "0.03"}, {"MODEL", "trinity"}},
+					PayloadAttributes: PayloadAttributes{"plan": "p01"},
+				},
+			},
+		},
+	}
+}
+
+func TestCartesianSweep(t *testing.T) {
+	base := sweepEvent()
+	seg, err := NewSweepEventGenerator(SweepEventGeneratorInput{
+		Event: base,
+		Axes: []SweepAxis{
+			{Name: "roughness", Values: []string{"0.02", "0.04"}},
+			{Name: "breach", Values: []string{"early", "mid", "late"}},
+		},
+		StartEventNumber: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []Event{}
+	for seg.HasNextEvent() {
+		events = append(events, seg.NextEvent())
+	}
+	if len(events) != 6 || seg.Count() != 6 {
+		t.Fatalf("expected 6 events, got %d", len(events))
+	}
+	last := events[5]
+	m := last.Manifests[0]
+	if last.EventNumber != 6 || last.Vars["roughness"] != "0.04" || last.Vars["breach"] != "late" {
+		t.Errorf("unexpected last event: %d %v", last.EventNumber, last.Vars)
+	}
+	if m.Inputs.Environment.GetVal("roughness") != "0.04" || len(m.Inputs.Environment) != 3 {
+		t.Errorf("expected the axis values to replace the base environment: %v", m.Inputs.Environment)
+	}
+	if m.Inputs.PayloadAttributes["breach"] != "late" || m.Inputs.PayloadAttributes["plan"] != "p01" {
+		t.Errorf("unexpected payload attributes: %v", m.Inputs.PayloadAttributes)
+	}
+	if events[0].ID == events[1].ID {
+		t.Error("expected unique event ids")
+	}
+	if len(base.Manifests[0].Inputs.PayloadAttributes) != 1 || base.Manifests[0].Inputs.Environment.GetVal("roughness") != "0.03" {
+		t.Error("the base event was modified")
+	}
+
+	axes := make([]SweepAxis, 63)
+	for i := range axes {
+		axes[i] = SweepAxis{Name: fmt.Sprintf("axis%d", i), Values: []string{"a", "b"}}
+	}
+	if _, err := NewSweepEventGenerator(SweepEventGeneratorInput{Event: sweepEvent(), Axes: axes}); err == nil {
+		t.Error("expected an error for a sweep with more events than an int64")
+	}
+}
+
+func TestZipAndListSweeps(t *testing.T) {
+	_, err := NewSweepEventGenerator(SweepEventGeneratorInput{
+		Event: sweepEvent(),
+		Mode:  SweepZip,
+		Axes: []SweepAxis{
+			{Name: "roughness", Values: []string{"0.02", "0.04"}},
+			{Name: "breach", Values: []string{"early"}},
+		},
+	})
+	if err == nil {
+		t.Error("expected an error for zipped axes of different lengths")
+	}
+
+	seg, err := NewSweepEventGenerator(SweepEventGeneratorInput{
+		Event: sweepEvent(),
+		Mode:  SweepList,
+		Sets: []map[string]string{
+			{"scenario": "base", "roughness": "0.03"},
+			{"scenario": "future", "roughness": "0.05"},
+		},
+		StartEventNumber: 100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = seg.WriteCSV(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "event_number,roughness,scenario\n100,0.03,base\n101,0.05,future\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected csv:\n%s", buf.String())
+	}
+
+	for seg.HasNextEvent() {
+		seg.NextEvent()
+	}
+	if event := seg.NextEvent(); len(event.Manifests) != 0 {
+		t.Errorf("expected an empty event after the last event: %+v", event)
+	}
+}
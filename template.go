@@ -0,0 +1,168 @@
+package cloudcompute
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	. "github.com/usace/cc-go-sdk"
+)
+
+// TemplateData is the data available to manifest templates.
+// Custom per-event variables are referenced as {{.Vars.name}}.
+// The hierarchical index is referenced as {{.Index.Realization}}, {{.Index.Block}} and {{.Index.Event}}
+// and is zero for events without an index.
+type TemplateData struct {
+	EventNumber int64
+	EventID     string
+	ComputeID   string
+	Vars        map[string]string
+	Index       EventIndex
+}
+
+// Determines if any of the manifest environment values, parameters, command arguments
+// or data source paths contain a template action
+func (cm *ComputeManifest) IsTemplated() bool {
+	for _, kvp := range cm.Inputs.Environment {
+		if isTemplate(kvp.Value) {
+			return true
+		}
+	}
+	for _, v := range cm.Inputs.Parameters {
+		if isTemplate(v) {
+			return true
+		}
+	}
+	for _, c := range cm.Command {
+		if isTemplate(c) {
+			return true
+		}
+	}
+	return templatedDataSources(cm.Inputs.DataSources) || templatedDataSources(cm.Outputs)
+}
+
+// Determines if any data source path contains a template
+func templatedDataSources(dss []DataSource) bool {
+	for _, ds := range dss {
+		for _, p := range ds.Paths {
+			if isTemplate(p) {
+				return true
+			}
+		}
+		for _, p := range ds.DataPaths {
+			if isTemplate(p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Renders the manifest templates for a single event.
+// Returns a copy of the manifest with the templates substituted.  The original manifest is not modified.
+// The payload of a templated manifest is specific to the event, so the copy
+// does not carry a previously written payload.
+func (cm *ComputeManifest) Render(data TemplateData) (ComputeManifest, error) {
+	m := *cm
+	if !cm.IsTemplated() {
+		return m, nil
+	}
+	r := templateRenderer{data: data}
+
+	m.Inputs.Environment = make(KeyValuePairs, len(cm.Inputs.Environment))
+	for i, kvp := range cm.Inputs.Environment {
+		m.Inputs.Environment[i] = KeyValuePair{kvp.Name, r.render(kvp.Value)}
+	}
+	if cm.Inputs.Parameters != nil {
+		m.Inputs.Parameters = make(map[string]string, len(cm.Inputs.Parameters))
+		for k, v := range cm.Inputs.Parameters {
+			m.Inputs.Parameters[k] = r.render(v)
+		}
+	}
+	m.Command = r.renderAll(cm.Command)
+	m.Inputs.DataSources = r.renderDataSources(cm.Inputs.DataSources)
+	m.Outputs = r.renderDataSources(cm.Outputs)
+
+	m.detachPayload()
+
+	if len(r.errs) > 0 {
+		return m, fmt.Errorf("Invalid template in manifest %s: %w", cm.ManifestName, r.errs)
+	}
+	return m, nil
+}
+
+// Renders the templates of every manifest in the event
+func (e *Event) Render(computeID string) error {
+	data := TemplateData{
+		EventNumber: e.EventNumber,
+		EventID:     e.ID.String(),
+		ComputeID:   computeID,
+		Vars:        e.Vars,
+	}
+	if e.Index != nil {
+		data.Index = *e.Index
+	}
+	manifests := make([]ComputeManifest, len(e.Manifests))
+	for i := range e.Manifests {
+		m, err := e.Manifests[i].Render(data)
+		if err != nil {
+			return fmt.Errorf("Event %d: %w", e.EventNumber, err)
+		}
+		manifests[i] = m
+	}
+	e.Manifests = manifests
+	return nil
+}
+
+func isTemplate(s string) bool {
+	return strings.Contains(s, "{{")
+}
+
+// renders templates collecting errors so that every problem in a manifest is reported
+type templateRenderer struct {
+	data TemplateData
+	errs ValidationErrors
+}
+
+func (r *templateRenderer) render(s string) string {
+	if !isTemplate(s) {
+		return s
+	}
+	t, err := template.New("").Option("missingkey=error").Parse(s)
+	if err != nil {
+		r.errs = append(r.errs, err.Error())
+		return s
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, r.data)
+	if err != nil {
+		r.errs = append(r.errs, err.Error())
+		return s
+	}
+	return buf.String()
+}
+
+func (r *templateRenderer) renderAll(vals []string) []string {
+	if vals == nil {
+		return nil
+	}
+	rendered := make([]string, len(vals))
+	for i, v := range vals {
+		rendered[i] = r.render(v)
+	}
+	return rendered
+}
+
+func (r *templateRenderer) renderDataSources(sources []DataSource) []DataSource {
+	if sources == nil {
+		return nil
+	}
+	rendered := make([]DataSource, len(sources))
+	for i, ds := range sources {
+		rendered[i] = ds
+		rendered[i].Paths = r.renderAll(ds.Paths)
+		rendered[i].DataPaths = r.renderAll(ds.DataPaths)
+	}
+	return rendered
+}
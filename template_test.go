@@ -0,0 +1,58 @@
+package cloudcompute
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/usace/cc-go-sdk"
+
+	"github.com/google/uuid"
+)
+
+func TestRenderManifest(t *testing.T) {
+	manifest := ComputeManifest{
+		ManifestName: "ras",
+		Command:      []string{"/app/ras", "{{.Vars.plan}}"},
+		Inputs: PluginInputs{
+			Environment: KeyValuePairs{{"OUTPUT_DIR", "/{{.ComputeID}}/{{.EventNumber}}"}},
+			Parameters:  map[string]string{"event": "{{.EventID}}"},
+			DataSources: []DataSource{{Name: "hydrograph", Paths: []string{"events/{{.EventNumber}}/flow.csv"}}},
+		},
+		Outputs: []DataSource{{Name: "depth-grid", Paths: []string{"static/depth.tif"}}},
+		Tags:    map[string]string{"payload": "shared", "owner": "ras"},
+	}
+	if !manifest.IsTemplated() {
+		t.Fatal("expected the manifest to be templated")
+	}
+	eventID := uuid.New()
+	m, err := manifest.Render(TemplateData{EventNumber: 17, EventID: eventID.String(), ComputeID: "c1", Vars: map[string]string{"plan": "p03"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Command[1] != "p03" || m.Inputs.Environment[0].Value != "/c1/17" || m.Inputs.Parameters["event"] != eventID.String() {
+		t.Errorf("templates were not rendered: %v %v %v", m.Command, m.Inputs.Environment, m.Inputs.Parameters)
+	}
+	if m.Inputs.DataSources[0].Paths[0] != "events/17/flow.csv" || m.Outputs[0].Paths[0] != "static/depth.tif" {
+		t.Errorf("data source paths were not rendered: %v %v", m.Inputs.DataSources, m.Outputs)
+	}
+	if _, ok := m.Tags["payload"]; ok || m.Tags["owner"] != "ras" {
+		t.Errorf("expected the shared payload tag to be dropped: %v", m.Tags)
+	}
+	if manifest.Command[1] != "{{.Vars.plan}}" || manifest.Inputs.DataSources[0].Paths[0] != "events/{{.EventNumber}}/flow.csv" {
+		t.Error("the original manifest was modified")
+	}
+}
+
+func TestRenderManifestErrors(t *testing.T) {
+	manifest := ComputeManifest{
+		ManifestName: "ras",
+		Command:      []string{"{{.Vars.missing}}", "{{.Unknown}}", "{{.EventNumber"},
+	}
+	_, err := manifest.Render(TemplateData{Vars: map[string]string{}})
+	if err == nil {
+		t.Fatal("expected template errors")
+	}
+	if n := strings.Count(err.Error(), ";"); n != 2 {
+		t.Errorf("expected three template errors: %s", err)
+	}
+}